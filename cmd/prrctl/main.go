@@ -0,0 +1,563 @@
+// prrctl — админская утилита для API сервиса назначения ревьюверов
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alinaaved/pr-reviewer/internal/client"
+)
+
+const usage = `prrctl — управление командами, пользователями и PR через HTTP API
+
+Использование:
+  prrctl [флаги] <команда> [аргументы]
+
+Команды:
+  team add -f teams.yaml          создать команды из YAML-файла
+  team get <team_name>            показать команду
+  roster sync -f roster.yaml [-dry-run]
+                                  привести все команды к ростеру (полный состав);
+                                  -dry-run — только показать изменения
+  user set-active <user_id> <true|false>
+                                  включить/выключить пользователя
+  user set-level <user_id> <junior|mid|senior>
+                                  сменить уровень пользователя
+  team set-composition <team_name> <min_senior_reviewers>
+                                  сколько senior нужно среди ревьюверов PR команды
+  user set-capacity <user_id> <max_open_reviews|none>
+                                  лимит открытых ревью пользователя (none — лимит команды)
+  team set-capacity <team_name> <max_open_reviews|none>
+                                  лимит открытых ревью по умолчанию для команды (none — без ограничения)
+  user reviews <user_id> [-status OPEN|MERGED|ALL]
+                                  PR, где пользователь назначен ревьювером (по умолчанию открытые)
+  pr get <pr_id>                  PR с ревьюверами и историей
+  pr reassign <pr_id> <old_user_id>
+                                  переназначить ревьювера
+  pr decline <pr_id> <busy|lacks_context|conflict_of_interest> [-user user_id]
+                                  отказаться от ревью (по умолчанию — за себя) с переназначением
+  pr set-reviewers <pr_id> <user_id,...>
+                                  закрепить ревьюверов за PR, остальные слоты — автоматически
+  pr merge <pr_id>                пометить PR как MERGED
+  pr import -f prs.ndjson [-batch N]
+                                  загрузить исторические PR (NDJSON, по PR на строку)
+  rule add <exclude|require> <author_id> <reviewer_id> [-team team_name]
+                                  правило назначения для пары (без -team — глобальное)
+  rule list [-author user_id] [-team team_name]
+                                  правила назначения
+  rule delete <id>                удалить правило
+  stats                           назначения по пользователям
+  stats fairness <team> [-from RFC3339] [-to RFC3339]
+                                  доли назначений против активного времени, Gini
+
+Флаги:
+`
+
+// app — общие параметры запуска команды
+type app struct {
+	api    *client.Client
+	format string // table | json
+	out    io.Writer
+}
+
+func main() {
+	fs := flag.NewFlagSet("prrctl", flag.ExitOnError)
+	addr := fs.String("addr", envOr("PRRCTL_ADDR", "http://localhost:8080"), "адрес API (PRRCTL_ADDR)")
+	token := fs.String("token", os.Getenv("PRRCTL_TOKEN"), "Bearer-токен (PRRCTL_TOKEN)")
+	format := fs.String("o", "table", "формат вывода: table | json")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[1:])
+
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "prrctl: unknown output format %q\n", *format)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	a := &app{api: client.New(*addr, *token), format: *format, out: os.Stdout}
+	if err := a.run(ctx, fs.Args()); err != nil {
+		var ue usageError
+		if errors.As(err, &ue) {
+			fmt.Fprintf(os.Stderr, "prrctl: %v\n\n", err)
+			fs.Usage()
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "prrctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// usageError — неверные аргументы командной строки
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+func (a *app) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("command is required")
+	}
+	cmd, rest := args[0], args[1:]
+	sub := ""
+	if len(rest) > 0 {
+		sub = rest[0]
+	}
+	switch {
+	case cmd == "team" && sub == "add":
+		return a.teamAdd(ctx, rest[1:])
+	case cmd == "team" && sub == "get":
+		return a.teamGet(ctx, rest[1:])
+	case cmd == "roster" && sub == "sync":
+		return a.rosterSync(ctx, rest[1:])
+	case cmd == "user" && sub == "set-level":
+		return a.userSetLevel(ctx, rest[1:])
+	case cmd == "team" && sub == "set-composition":
+		return a.teamSetComposition(ctx, rest[1:])
+	case cmd == "user" && sub == "set-capacity":
+		return a.userSetCapacity(ctx, rest[1:])
+	case cmd == "team" && sub == "set-capacity":
+		return a.teamSetCapacity(ctx, rest[1:])
+	case cmd == "user" && sub == "set-active":
+		return a.userSetActive(ctx, rest[1:])
+	case cmd == "user" && sub == "reviews":
+		return a.userReviews(ctx, rest[1:])
+	case cmd == "pr" && sub == "reassign":
+		return a.prReassign(ctx, rest[1:])
+	case cmd == "pr" && sub == "decline":
+		return a.prDecline(ctx, rest[1:])
+	case cmd == "pr" && sub == "set-reviewers":
+		return a.prSetReviewers(ctx, rest[1:])
+	case cmd == "pr" && sub == "get":
+		return a.prGet(ctx, rest[1:])
+	case cmd == "pr" && sub == "import":
+		return a.prImport(ctx, rest[1:])
+	case cmd == "pr" && sub == "merge":
+		return a.prMerge(ctx, rest[1:])
+	case cmd == "rule" && sub == "add":
+		return a.ruleAdd(ctx, rest[1:])
+	case cmd == "rule" && sub == "list":
+		return a.ruleList(ctx, rest[1:])
+	case cmd == "rule" && sub == "delete":
+		return a.ruleDelete(ctx, rest[1:])
+	case cmd == "stats" && sub == "fairness":
+		return a.statsFairness(ctx, rest[1:])
+	case cmd == "stats":
+		return a.stats(ctx)
+	default:
+		return usageError(fmt.Sprintf("unknown command %q", strings.Join(args, " ")))
+	}
+}
+
+func (a *app) teamAdd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("team add", flag.ContinueOnError)
+	file := fs.String("f", "", "YAML-файл с командами")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if *file == "" {
+		return usageError("team add: -f is required")
+	}
+	teams, err := readTeamsFile(*file)
+	if err != nil {
+		return err
+	}
+	for _, t := range teams {
+		created, err := a.api.TeamAdd(ctx, t)
+		if err != nil {
+			return fmt.Errorf("team %s: %w", t.TeamName, err)
+		}
+		if err := a.printTeam(created); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *app) teamGet(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return usageError("team get: team_name is required")
+	}
+	t, err := a.api.TeamGet(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.printTeam(t)
+}
+
+func (a *app) userSetActive(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return usageError("user set-active: user_id and true|false are required")
+	}
+	active, err := strconv.ParseBool(args[1])
+	if err != nil {
+		return usageError("user set-active: want true or false, got " + args[1])
+	}
+	u, err := a.api.SetIsActive(ctx, args[0], active)
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(u)
+	}
+	return a.table([]string{"USER_ID", "USERNAME", "TEAM", "ACTIVE"},
+		[][]string{{u.UserID, u.Username, u.TeamName, strconv.FormatBool(u.IsActive)}})
+}
+
+func (a *app) userSetLevel(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return usageError("user set-level: user_id and level are required")
+	}
+	u, err := a.api.SetLevel(ctx, args[0], strings.ToLower(args[1]))
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(u)
+	}
+	return a.table([]string{"USER_ID", "USERNAME", "TEAM", "LEVEL"},
+		[][]string{{u.UserID, u.Username, u.TeamName, u.Level}})
+}
+
+func (a *app) teamSetComposition(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return usageError("team set-composition: team_name and min_senior_reviewers are required")
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil {
+		return usageError("team set-composition: min_senior_reviewers must be a number")
+	}
+	t, err := a.api.SetComposition(ctx, args[0], n)
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(t)
+	}
+	return a.table([]string{"TEAM", "MIN_SENIOR_REVIEWERS"}, [][]string{{t.TeamName, strconv.Itoa(t.MinSeniorReviewers)}})
+}
+
+// parseLimit разбирает лимит открытых ревью: число или none (nil)
+func parseLimit(s string) (*int, error) {
+	if strings.EqualFold(s, "none") {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return nil, errors.New("max_open_reviews must be a positive number or none")
+	}
+	return &n, nil
+}
+
+// formatLimit — лимит для таблицы; "-" — без ограничения
+func formatLimit(n *int) string {
+	if n == nil {
+		return "-"
+	}
+	return strconv.Itoa(*n)
+}
+
+func (a *app) userSetCapacity(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return usageError("user set-capacity: user_id and max_open_reviews are required")
+	}
+	limit, err := parseLimit(args[1])
+	if err != nil {
+		return usageError("user set-capacity: " + err.Error())
+	}
+	u, err := a.api.SetCapacity(ctx, args[0], limit)
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(u)
+	}
+	return a.table([]string{"USER_ID", "USERNAME", "TEAM", "MAX_OPEN_REVIEWS"},
+		[][]string{{u.UserID, u.Username, u.TeamName, formatLimit(u.MaxOpenReviews)}})
+}
+
+func (a *app) teamSetCapacity(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return usageError("team set-capacity: team_name and max_open_reviews are required")
+	}
+	limit, err := parseLimit(args[1])
+	if err != nil {
+		return usageError("team set-capacity: " + err.Error())
+	}
+	t, err := a.api.SetTeamCapacity(ctx, args[0], limit)
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(t)
+	}
+	return a.table([]string{"TEAM", "MAX_OPEN_REVIEWS"}, [][]string{{t.TeamName, formatLimit(t.MaxOpenReviews)}})
+}
+
+func (a *app) userReviews(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("user reviews", flag.ContinueOnError)
+	status := fs.String("status", "OPEN", "OPEN | MERGED | ALL")
+	// user_id может стоять и до, и после флагов
+	var userID string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		userID, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if userID == "" && fs.NArg() > 0 {
+		userID = fs.Arg(0)
+	}
+	if userID == "" {
+		return usageError("user reviews: user_id is required")
+	}
+	res, err := a.api.GetReview(ctx, userID, *status)
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(res)
+	}
+	rows := make([][]string, 0, len(res.PullRequests))
+	for _, pr := range res.PullRequests {
+		age := ""
+		if pr.AgeSeconds != nil {
+			age = (time.Duration(*pr.AgeSeconds) * time.Second).String()
+		}
+		rows = append(rows, []string{pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, age})
+	}
+	return a.table([]string{"PR_ID", "NAME", "AUTHOR", "STATUS", "AGE"}, rows)
+}
+
+func (a *app) prReassign(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return usageError("pr reassign: pr_id and old_user_id are required")
+	}
+	res, err := a.api.Reassign(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(res)
+	}
+	return a.table([]string{"PR_ID", "STATUS", "REVIEWERS", "REPLACED_BY"},
+		[][]string{{res.PR.PullRequestID, res.PR.Status, strings.Join(res.PR.Assigned, ","), res.ReplacedBy}})
+}
+
+func (a *app) prDecline(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("pr decline", flag.ContinueOnError)
+	user := fs.String("user", "", "ревьювер (по умолчанию — вызывающий)")
+	if len(args) < 2 {
+		return usageError("pr decline: pr_id and reason are required")
+	}
+	if err := fs.Parse(args[2:]); err != nil {
+		return usageError(err.Error())
+	}
+	res, err := a.api.Decline(ctx, args[0], *user, strings.ToUpper(args[1]))
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(res)
+	}
+	return a.table([]string{"PR_ID", "STATUS", "REVIEWERS", "REPLACED_BY", "REASON"},
+		[][]string{{res.PR.PullRequestID, res.PR.Status, strings.Join(res.PR.Assigned, ","), res.ReplacedBy, res.Reason}})
+}
+
+func (a *app) prSetReviewers(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return usageError("pr set-reviewers: pr_id and user_id list are required")
+	}
+	pr, err := a.api.SetReviewers(ctx, args[0], strings.Split(args[1], ","))
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(pr)
+	}
+	return a.table([]string{"PR_ID", "STATUS", "REVIEWERS", "PINNED"},
+		[][]string{{pr.PullRequestID, pr.Status, strings.Join(pr.Assigned, ","), strings.Join(pr.Pinned, ",")}})
+}
+
+func (a *app) prGet(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return usageError("pr get: pr_id is required")
+	}
+	pr, err := a.api.GetPR(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(pr)
+	}
+	rows := make([][]string, 0, len(pr.History.Events))
+	for _, ev := range pr.History.Events {
+		detail := ev.OldUserID
+		if ev.NewUserID != "" {
+			detail += " -> " + ev.NewUserID
+		}
+		if ev.Reason != "" {
+			detail += " (" + ev.Reason + ")"
+		}
+		rows = append(rows, []string{ev.At.Format("2006-01-02 15:04:05Z07:00"), ev.Kind, strings.TrimSpace(detail)})
+	}
+	if err := a.table([]string{"PR_ID", "NAME", "AUTHOR", "STATUS", "REVIEWERS", "REASSIGNS"},
+		[][]string{{pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status,
+			strings.Join(pr.Assigned, ","), fmt.Sprint(pr.History.Reassignments)}}); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out)
+	return a.table([]string{"AT", "EVENT", "DETAIL"}, rows)
+}
+
+func (a *app) prImport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("pr import", flag.ContinueOnError)
+	file := fs.String("f", "", "NDJSON-файл с PR")
+	batch := fs.Int("batch", 0, "размер пачки (по умолчанию — серверный)")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if *file == "" {
+		return usageError("pr import: -f is required")
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	res, err := a.api.ImportPRs(ctx, f, *batch)
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(res)
+	}
+	if _, err := fmt.Fprintf(a.out, "imported=%d failed=%d\n", res.Imported, res.Failed); err != nil {
+		return err
+	}
+	if len(res.Errors) == 0 {
+		return nil
+	}
+	rows := make([][]string, 0, len(res.Errors))
+	for _, e := range res.Errors {
+		rows = append(rows, []string{strconv.Itoa(e.Line), e.PullRequestID, e.Code, e.Message})
+	}
+	fmt.Fprintln(a.out)
+	return a.table([]string{"LINE", "PR_ID", "CODE", "MESSAGE"}, rows)
+}
+
+func (a *app) prMerge(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return usageError("pr merge: pr_id is required")
+	}
+	pr, err := a.api.Merge(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(pr)
+	}
+	merged := ""
+	if pr.MergedAt != nil {
+		merged = pr.MergedAt.Format("2006-01-02 15:04:05Z07:00")
+	}
+	return a.table([]string{"PR_ID", "NAME", "AUTHOR", "STATUS", "REVIEWERS", "MERGED_AT"},
+		[][]string{{pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, strings.Join(pr.Assigned, ","), merged}})
+}
+
+func (a *app) stats(ctx context.Context) error {
+	items, err := a.api.AssignmentsByUser(ctx)
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(items)
+	}
+	rows := make([][]string, 0, len(items))
+	for _, it := range items {
+		rows = append(rows, []string{it.UserID, strconv.FormatInt(it.Count, 10)})
+	}
+	return a.table([]string{"USER_ID", "ASSIGNMENTS"}, rows)
+}
+
+func (a *app) statsFairness(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("stats fairness", flag.ContinueOnError)
+	from := fs.String("from", "", "начало окна, RFC3339 (по умолчанию to минус 14 дней)")
+	to := fs.String("to", "", "конец окна, RFC3339 (по умолчанию сейчас)")
+	var team string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		team, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if team == "" && fs.NArg() > 0 {
+		team = fs.Arg(0)
+	}
+	if team == "" {
+		return usageError("stats fairness: team_name is required")
+	}
+	rep, err := a.api.Fairness(ctx, team, *from, *to)
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(rep)
+	}
+	pct := func(v float64) string { return strconv.FormatFloat(v*100, 'f', 1, 64) + "%" }
+	rows := make([][]string, 0, len(rep.Members))
+	for _, m := range rep.Members {
+		rows = append(rows, []string{m.UserID, strconv.FormatFloat(m.ActiveHours, 'f', 0, 64), pct(m.ActiveShare),
+			strconv.FormatInt(m.Assignments, 10), pct(m.AssignmentShare), strconv.FormatFloat(m.LoadRatio, 'f', 2, 64), m.Outlier})
+	}
+	if err := a.table([]string{"USER_ID", "ACTIVE_H", "ACTIVE", "ASSIGNED", "SHARE", "RATIO", "OUTLIER"}, rows); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(a.out, "\ngini=%.3f assignments=%d\n", rep.Gini, rep.TotalAssignments)
+	return err
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func (a *app) json(v any) error {
+	enc := json.NewEncoder(a.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (a *app) table(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	writeRow(tw, header)
+	for _, r := range rows {
+		writeRow(tw, r)
+	}
+	return tw.Flush()
+}
+
+func writeRow(w io.Writer, cols []string) {
+	for i, c := range cols {
+		if i > 0 {
+			_, _ = io.WriteString(w, "\t")
+		}
+		_, _ = io.WriteString(w, c)
+	}
+	_, _ = io.WriteString(w, "\n")
+}
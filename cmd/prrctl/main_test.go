@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alinaaved/pr-reviewer/internal/client"
+	httpapi "github.com/alinaaved/pr-reviewer/internal/http"
+)
+
+func TestTeamAddFromYAML(t *testing.T) {
+	var got []httpapi.Team
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/team/add" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected request %s auth=%q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var in httpapi.Team
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		got = append(got, in)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{"team": in})
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "teams.yaml")
+	_ = os.WriteFile(path, []byte(`
+teams:
+  - team_name: backend
+    members:
+      - {user_id: u1, username: Alice}
+      - {user_id: u2, username: Bob, is_active: false}
+  - team_name: payments
+    members:
+      - {user_id: u9, username: Zoe}
+`), 0o600)
+
+	var out bytes.Buffer
+	a := &app{api: client.New(srv.URL, "secret"), format: "table", out: &out}
+	if err := a.run(context.Background(), []string{"team", "add", "-f", path}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(got) != 2 || got[0].TeamName != "backend" || got[1].TeamName != "payments" {
+		t.Fatalf("teams sent: %+v", got)
+	}
+	if !got[0].Members[0].Active() || got[0].Members[1].Active() {
+		t.Fatalf("is_active defaults are wrong: %+v", got[0].Members)
+	}
+	if !strings.Contains(out.String(), "backend  u2       Bob       false") {
+		t.Fatalf("table output:\n%s", out.String())
+	}
+}
+
+func TestAPIErrorAndUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"error":{"code":"NO_CANDIDATE","message":"no active replacement candidate in team"}}`))
+	}))
+	defer srv.Close()
+
+	a := &app{api: client.New(srv.URL, ""), format: "json", out: &bytes.Buffer{}}
+	err := a.run(context.Background(), []string{"pr", "reassign", "pr-1", "u2"})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "NO_CANDIDATE" || apiErr.Status != http.StatusConflict {
+		t.Fatalf("err=%v", err)
+	}
+
+	var ue usageError
+	if err := a.run(context.Background(), []string{"pr", "merge"}); !errors.As(err, &ue) {
+		t.Fatalf("missing argument: err=%v", err)
+	}
+	if err := a.run(context.Background(), []string{"deploy"}); !errors.As(err, &ue) {
+		t.Fatalf("unknown command: err=%v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	httpapi "github.com/alinaaved/pr-reviewer/internal/http"
+)
+
+func (a *app) rosterSync(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("roster sync", flag.ContinueOnError)
+	file := fs.String("f", "", "YAML-файл с ростером (формат как у team add)")
+	dryRun := fs.Bool("dry-run", false, "только показать изменения")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if *file == "" {
+		return usageError("roster sync: -f is required")
+	}
+	teams, err := readTeamsFile(*file)
+	if err != nil {
+		return err
+	}
+	res, err := a.api.RosterSync(ctx, teams, *dryRun)
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.json(res)
+	}
+	rows := diffRows(res.Diff)
+	if len(rows) == 0 {
+		_, err := fmt.Fprintln(a.out, "roster is up to date")
+		return err
+	}
+	if res.DryRun {
+		if _, err := fmt.Fprintln(a.out, "dry run: nothing was changed"); err != nil {
+			return err
+		}
+	}
+	return a.table([]string{"ACTION", "ID", "DETAIL"}, rows)
+}
+
+// diffRows раскладывает diff ростера в строки таблицы
+func diffRows(d httpapi.RosterDiff) [][]string {
+	var rows [][]string
+	for _, t := range d.TeamsCreated {
+		rows = append(rows, []string{"create-team", t, ""})
+	}
+	for _, u := range d.UsersCreated {
+		rows = append(rows, []string{"create-user", u, ""})
+	}
+	for _, m := range d.UsersMoved {
+		rows = append(rows, []string{"move-user", m.UserID, m.From + " -> " + m.To})
+	}
+	for _, u := range d.UsersRenamed {
+		rows = append(rows, []string{"rename-user", u, ""})
+	}
+	for _, u := range d.UsersLeveled {
+		rows = append(rows, []string{"set-level", u, ""})
+	}
+	for _, u := range d.UsersActivated {
+		rows = append(rows, []string{"activate", u, ""})
+	}
+	for _, u := range d.UsersDeactivated {
+		rows = append(rows, []string{"deactivate", u, ""})
+	}
+	for _, ra := range d.Reassignments {
+		detail := ra.OldUserID + " -> "
+		switch {
+		case ra.Unassigned:
+			detail += "(no candidate, unassigned)"
+		case ra.NewUserID == "":
+			detail += "?"
+		default:
+			detail += ra.NewUserID
+		}
+		rows = append(rows, []string{"reassign", ra.PullRequestID, detail})
+	}
+	return rows
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+	"strings"
+
+	httpapi "github.com/alinaaved/pr-reviewer/internal/http"
+)
+
+func (a *app) ruleAdd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rule add", flag.ContinueOnError)
+	team := fs.String("team", "", "команда правила (по умолчанию — глобальное)")
+	if len(args) < 3 {
+		return usageError("rule add: kind, author_id and reviewer_id are required")
+	}
+	if err := fs.Parse(args[3:]); err != nil {
+		return usageError(err.Error())
+	}
+	rule, err := a.api.AddRule(ctx, httpapi.ReviewerRule{
+		Kind:       strings.ToUpper(args[0]),
+		AuthorID:   args[1],
+		ReviewerID: args[2],
+		TeamName:   *team,
+	})
+	if err != nil {
+		return err
+	}
+	return a.rules([]httpapi.ReviewerRule{rule})
+}
+
+func (a *app) ruleList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rule list", flag.ContinueOnError)
+	author := fs.String("author", "", "только правила автора")
+	team := fs.String("team", "", "правила команды и глобальные")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	rules, err := a.api.ListRules(ctx, *author, *team)
+	if err != nil {
+		return err
+	}
+	return a.rules(rules)
+}
+
+func (a *app) ruleDelete(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return usageError("rule delete: id is required")
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return usageError("rule delete: id must be a number")
+	}
+	rule, err := a.api.DeleteRule(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.rules([]httpapi.ReviewerRule{rule})
+}
+
+func (a *app) rules(rules []httpapi.ReviewerRule) error {
+	if a.format == "json" {
+		return a.json(rules)
+	}
+	rows := make([][]string, 0, len(rules))
+	for _, x := range rules {
+		team := x.TeamName
+		if team == "" {
+			team = "*"
+		}
+		rows = append(rows, []string{strconv.FormatInt(x.ID, 10), x.Kind, team, x.AuthorID, x.ReviewerID})
+	}
+	return a.table([]string{"ID", "KIND", "TEAM", "AUTHOR", "REVIEWER"}, rows)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	httpapi "github.com/alinaaved/pr-reviewer/internal/http"
+)
+
+// teamsFile — YAML с командами: список под teams: или одна команда в корне
+type teamsFile struct {
+	Teams    []teamYAML `yaml:"teams"`
+	teamYAML `yaml:",inline"`
+}
+
+type teamYAML struct {
+	TeamName           string       `yaml:"team_name"`
+	Members            []memberYAML `yaml:"members"`
+	MinSeniorReviewers int          `yaml:"min_senior_reviewers"`
+	MaxOpenReviews     *int         `yaml:"max_open_reviews"`
+}
+
+type memberYAML struct {
+	UserID   string `yaml:"user_id"`
+	Username string `yaml:"username"`
+	IsActive *bool  `yaml:"is_active"` // не указан — активен
+	Level    string `yaml:"level"`     // junior | mid | senior
+	// MaxOpenReviews — лимит открытых ревью; не указан — лимит команды
+	MaxOpenReviews *int `yaml:"max_open_reviews"`
+}
+
+func readTeamsFile(path string) ([]httpapi.Team, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f teamsFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	src := f.Teams
+	if f.TeamName != "" {
+		src = append(src, f.teamYAML)
+	}
+	if len(src) == 0 {
+		return nil, errors.New(path + ": no teams found")
+	}
+
+	out := make([]httpapi.Team, 0, len(src))
+	for _, t := range src {
+		if t.TeamName == "" {
+			return nil, fmt.Errorf("%s: team_name is required", path)
+		}
+		team := httpapi.Team{TeamName: t.TeamName, MinSeniorReviewers: t.MinSeniorReviewers, MaxOpenReviews: t.MaxOpenReviews}
+		for _, m := range t.Members {
+			if m.UserID == "" {
+				return nil, fmt.Errorf("%s: team %s: member without user_id", path, t.TeamName)
+			}
+			// is_active не указан — сервер считает участника активным
+			team.Members = append(team.Members, httpapi.TeamMember{
+				UserID: m.UserID, Username: m.Username, IsActive: m.IsActive, Level: m.Level,
+				MaxOpenReviews: m.MaxOpenReviews,
+			})
+		}
+		out = append(out, team)
+	}
+	return out, nil
+}
+
+func (a *app) printTeam(t httpapi.Team) error {
+	if a.format == "json" {
+		return a.json(t)
+	}
+	rows := make([][]string, 0, len(t.Members))
+	for _, m := range t.Members {
+		limit := m.MaxOpenReviews
+		if limit == nil {
+			limit = t.MaxOpenReviews // свой лимит не задан — действует командный
+		}
+		rows = append(rows, []string{t.TeamName, m.UserID, m.Username, strconv.FormatBool(m.Active()), m.Level, formatLimit(limit)})
+	}
+	return a.table([]string{"TEAM", "USER_ID", "USERNAME", "ACTIVE", "LEVEL", "MAX_OPEN_REVIEWS"}, rows)
+}
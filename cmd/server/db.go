@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// connectBackoff — параметры повторных попыток подключения к БД при старте
+type connectBackoff struct {
+	Initial time.Duration // первая пауза
+	Max     time.Duration // потолок паузы
+	Timeout time.Duration // общее время на подключение
+}
+
+// openWithRetry открывает БД, повторяя попытки с экспоненциальной паузой,
+// пока не выйдет Timeout или не отменят ctx
+func openWithRetry(ctx context.Context, dsn string, cfg *gorm.Config, b connectBackoff, logger *slog.Logger) (*gorm.DB, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.Timeout)
+	defer cancel()
+
+	wait := b.Initial
+	for attempt := 1; ; attempt++ {
+		db, err := gorm.Open(postgres.Open(dsn), cfg)
+		if err == nil {
+			return db, nil
+		}
+		if db != nil {
+			// пул открыт, хотя ping не прошёл — закрываем перед следующей попыткой
+			if sqlDB, e := db.DB(); e == nil {
+				_ = sqlDB.Close()
+			}
+		}
+		logger.Warn("db connect failed, retrying", "attempt", attempt, "retry_in", wait.String(), "err", err)
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, b.Max)
+	}
+}
@@ -0,0 +1,12 @@
+CREATE TABLE pr_events (
+  id          BIGSERIAL PRIMARY KEY,
+  pr_id       TEXT NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+  kind        TEXT NOT NULL,
+  old_user_id TEXT,
+  new_user_id TEXT,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_pr_events_pr ON pr_events(pr_id, id);
+
+INSERT INTO schema_migrations (version) VALUES (3);
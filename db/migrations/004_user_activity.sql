@@ -0,0 +1,14 @@
+CREATE TABLE user_activity (
+  id         BIGSERIAL PRIMARY KEY,
+  user_id    TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+  is_active  BOOLEAN NOT NULL,
+  changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_user_activity_user ON user_activity(user_id, changed_at);
+
+-- у существующих пользователей истории нет: считаем, что текущее состояние было всегда
+INSERT INTO user_activity (user_id, is_active, changed_at)
+SELECT user_id, is_active, 'epoch' FROM users;
+
+INSERT INTO schema_migrations (version) VALUES (4);
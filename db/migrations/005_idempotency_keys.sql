@@ -0,0 +1,15 @@
+CREATE TABLE idempotency_keys (
+  scope        TEXT NOT NULL,        -- вызывающий, метод и путь
+  key          TEXT NOT NULL,        -- значение заголовка Idempotency-Key
+  request_hash TEXT NOT NULL,        -- sha256 тела запроса
+  status_code  INT,                  -- NULL, пока первый запрос выполняется
+  content_type TEXT NOT NULL DEFAULT '',
+  body         BYTEA,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at   TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires ON idempotency_keys(expires_at);
+
+INSERT INTO schema_migrations (version) VALUES (5);
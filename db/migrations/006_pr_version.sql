@@ -0,0 +1,4 @@
+-- версия PR для оптимистичной блокировки (ETag / If-Match): растёт при каждом изменении ревьюверов и merge
+ALTER TABLE pull_requests ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+INSERT INTO schema_migrations (version) VALUES (6);
@@ -0,0 +1,5 @@
+-- причина отказа ревьювера (POST /pullRequest/decline): у REASSIGNED/UNASSIGNED, вызванных отказом
+ALTER TABLE pr_events ADD COLUMN reason TEXT
+  CHECK (reason IN ('BUSY', 'LACKS_CONTEXT', 'CONFLICT_OF_INTEREST'));
+
+INSERT INTO schema_migrations (version) VALUES (7);
@@ -0,0 +1,4 @@
+-- ревьювер, выбранный вручную (requested_reviewers / setReviewers): автоматическое переназначение его не снимает
+ALTER TABLE pr_reviewers ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE;
+
+INSERT INTO schema_migrations (version) VALUES (8);
@@ -0,0 +1,15 @@
+-- правила назначения для пар автор/ревьювер:
+-- EXCLUDE — reviewer_id никогда не назначается на PR author_id (ментор/подопечный, конфликт интересов);
+-- REQUIRE — среди ревьюверов PR author_id всегда есть кто-то из его REQUIRE-правил (если он активен)
+CREATE TABLE reviewer_rules (
+  id          BIGSERIAL PRIMARY KEY,
+  kind        TEXT NOT NULL CHECK (kind IN ('EXCLUDE', 'REQUIRE')),
+  team_name   TEXT REFERENCES teams(team_name) ON DELETE CASCADE, -- NULL — глобальное правило
+  author_id   TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+  reviewer_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CHECK (author_id <> reviewer_id),
+  UNIQUE (author_id, reviewer_id)
+);
+
+INSERT INTO schema_migrations (version) VALUES (9);
@@ -0,0 +1,9 @@
+-- уровень пользователя и требование команды к составу ревьюверов
+ALTER TABLE users ADD COLUMN level TEXT NOT NULL DEFAULT 'mid'
+  CHECK (level IN ('junior', 'mid', 'senior'));
+
+-- сколько senior должно быть среди ревьюверов PR авторов команды (если в команде столько активных)
+ALTER TABLE teams ADD COLUMN min_senior_reviewers SMALLINT NOT NULL DEFAULT 0
+  CHECK (min_senior_reviewers BETWEEN 0 AND 2);
+
+INSERT INTO schema_migrations (version) VALUES (10);
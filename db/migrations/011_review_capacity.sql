@@ -0,0 +1,6 @@
+-- сколько открытых ревью можно держать одновременно: у пользователя, иначе — по умолчанию команды;
+-- NULL — без ограничения
+ALTER TABLE users ADD COLUMN max_open_reviews INTEGER CHECK (max_open_reviews > 0);
+ALTER TABLE teams ADD COLUMN max_open_reviews INTEGER CHECK (max_open_reviews > 0);
+
+INSERT INTO schema_migrations (version) VALUES (11);
@@ -0,0 +1,4 @@
+-- ETag сохранённого ответа: повтор с Idempotency-Key отдаёт ту же версию PR для If-Match
+ALTER TABLE idempotency_keys ADD COLUMN etag TEXT NOT NULL DEFAULT '';
+
+INSERT INTO schema_migrations (version) VALUES (12);
@@ -0,0 +1,126 @@
+// Package auth содержит аутентификацию вызывающих и правила доступа к данным
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Role — глобальная роль вызывающего
+type Role string
+
+// Поддерживаемые роли
+const (
+	RoleAdmin  Role = "admin"  // полный доступ
+	RoleWriter Role = "writer" // чтение и изменение данных
+	RoleReader Role = "reader" // только чтение
+)
+
+// ErrUnauthenticated — запрос без валидных учётных данных
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Principal — аутентифицированный вызывающий
+type Principal struct {
+	Subject string // идентификатор вызывающего для логов (user_id или префикс токена)
+	ID      string // уникальный идентификатор вызывающего (хеш токена); в логи не пишется
+	Role    Role
+	Team    string // если задано — доступ ограничен этой командой
+}
+
+// CanWrite сообщает, разрешено ли вызывающему изменять данные
+func (p *Principal) CanWrite() bool {
+	return p.Role == RoleAdmin || p.Role == RoleWriter
+}
+
+// CanAccessTeam сообщает, разрешено ли вызывающему изменять данные команды team
+func (p *Principal) CanAccessTeam(team string) bool {
+	return p.Team == "" || p.Team == team
+}
+
+// UserID возвращает пользователя, от имени которого действует вызывающий (пользователь JWT).
+// У статических токенов пользователя нет — пустая строка
+func (p *Principal) UserID() string {
+	if uid, ok := strings.CutPrefix(p.ID, "jwt:"); ok {
+		return uid
+	}
+	return ""
+}
+
+// Authenticator извлекает Principal из запроса
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+type ctxKey struct{}
+
+// WithPrincipal кладёт Principal в контекст
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext достаёт Principal из контекста (nil, если аутентификация выключена)
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(ctxKey{}).(*Principal)
+	return p
+}
+
+// BearerToken возвращает токен из заголовка Authorization: Bearer ...
+func BearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if len(h) < len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(h[len(prefix):])
+}
+
+// StaticTokens — аутентификация по заранее выданным API-токенам
+type StaticTokens map[string]Principal
+
+// ParseStaticTokens разбирает строку вида "tok1=admin,tok2=writer:backend"
+// (<токен>=<роль>[:<команда>] через запятую)
+func ParseStaticTokens(s string) (StaticTokens, error) {
+	out := StaticTokens{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		tok, spec, ok := strings.Cut(item, "=")
+		if !ok || tok == "" {
+			return nil, fmt.Errorf("auth: invalid token spec %q", item)
+		}
+		role, team, _ := strings.Cut(spec, ":")
+		sum := sha256.Sum256([]byte(tok))
+		p := Principal{Subject: "token:" + tokenName(tok), ID: "token:" + hex.EncodeToString(sum[:]), Role: Role(role), Team: team}
+		switch p.Role {
+		case RoleAdmin, RoleWriter, RoleReader:
+		default:
+			return nil, fmt.Errorf("auth: unknown role %q", role)
+		}
+		out[tok] = p
+	}
+	return out, nil
+}
+
+// tokenName возвращает безопасный для логов префикс токена
+func tokenName(tok string) string {
+	if len(tok) > 4 {
+		return tok[:4] + "…"
+	}
+	return tok
+}
+
+// Authenticate реализует Authenticator
+func (s StaticTokens) Authenticate(r *http.Request) (*Principal, error) {
+	p, ok := s[BearerToken(r)]
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	return &p, nil
+}
@@ -0,0 +1,75 @@
+package auth_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alinaaved/pr-reviewer/internal/auth"
+)
+
+func TestParseStaticTokens(t *testing.T) {
+	tokens, err := auth.ParseStaticTokens("root-secret=admin, ci-backend=writer:backend,ro=reader")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(tokens) != 3 {
+		t.Fatalf("tokens=%d (want 3)", len(tokens))
+	}
+	ci := tokens["ci-backend"]
+	if ci.Role != auth.RoleWriter || ci.Team != "backend" {
+		t.Fatalf("unexpected ci token: %+v", ci)
+	}
+	if !ci.CanWrite() || !ci.CanAccessTeam("backend") || ci.CanAccessTeam("payments") {
+		t.Fatalf("ci token scope is wrong: %+v", ci)
+	}
+	root := tokens["root-secret"]
+	if !root.CanAccessTeam("payments") {
+		t.Fatalf("admin token must access any team")
+	}
+	if ro := tokens["ro"]; ro.CanWrite() {
+		t.Fatalf("reader token must be read-only")
+	}
+
+	if _, err := auth.ParseStaticTokens("x=superuser"); err == nil {
+		t.Fatalf("unknown role accepted")
+	}
+	if _, err := auth.ParseStaticTokens("=admin"); err == nil {
+		t.Fatalf("empty token accepted")
+	}
+}
+
+func TestStaticTokensAuthenticate(t *testing.T) {
+	tokens, _ := auth.ParseStaticTokens("ci-backend=writer:backend")
+
+	r := httptest.NewRequest("POST", "/pullRequest/create", nil)
+	if _, err := tokens.Authenticate(r); err != auth.ErrUnauthenticated {
+		t.Fatalf("no header: err=%v", err)
+	}
+
+	r.Header.Set("Authorization", "Bearer wrong")
+	if _, err := tokens.Authenticate(r); err != auth.ErrUnauthenticated {
+		t.Fatalf("wrong token: err=%v", err)
+	}
+
+	r.Header.Set("Authorization", "bearer ci-backend")
+	p, err := tokens.Authenticate(r)
+	if err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+	if p.Team != "backend" {
+		t.Fatalf("team=%q", p.Team)
+	}
+}
+
+func TestStaticTokensID(t *testing.T) {
+	tokens, _ := auth.ParseStaticTokens("team-backend=writer:backend,team-payments=writer:payments")
+	a, b := tokens["team-backend"], tokens["team-payments"]
+	// префикс для логов совпадает, идентификатор — нет
+	if a.Subject != b.Subject || a.ID == b.ID || a.ID == "" {
+		t.Fatalf("a=%+v b=%+v", a, b)
+	}
+	// статический токен не представляет пользователя
+	if a.UserID() != "" {
+		t.Fatalf("UserID=%q", a.UserID())
+	}
+}
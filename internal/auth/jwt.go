@@ -0,0 +1,269 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTConfig — параметры проверки JWT от OIDC-провайдера
+type JWTConfig struct {
+	JWKS      string // путь к файлу или http(s)-URL с JWKS
+	Issuer    string // ожидаемый iss (пусто — не проверяем)
+	Audience  string // ожидаемый aud (пусто — не проверяем)
+	UserClaim string // claim с user_id (по умолчанию sub)
+	TeamClaim string // claim с командой (пусто — без ограничения по команде)
+	Role      Role   // роль для всех JWT-пользователей (по умолчанию writer)
+
+	// RefreshInterval — минимальный интервал перечитывания JWKS при неизвестном kid
+	RefreshInterval time.Duration
+}
+
+// JWT — Authenticator, проверяющий RS256/ES256 JWT по JWKS
+type JWT struct {
+	cfg    JWTConfig
+	client *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewJWT загружает JWKS и возвращает готовый Authenticator
+func NewJWT(ctx context.Context, cfg JWTConfig) (*JWT, error) {
+	if cfg.JWKS == "" {
+		return nil, errors.New("auth: JWKS source is required")
+	}
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = "sub"
+	}
+	if cfg.Role == "" {
+		cfg.Role = RoleWriter
+	}
+	if cfg.RefreshInterval == 0 {
+		cfg.RefreshInterval = 5 * time.Minute
+	}
+	j := &JWT{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+	if err := j.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Authenticate реализует Authenticator
+func (j *JWT) Authenticate(r *http.Request) (*Principal, error) {
+	raw := BearerToken(r)
+	if raw == "" {
+		return nil, ErrUnauthenticated
+	}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithExpirationRequired(),
+	}
+	if j.cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(j.cfg.Issuer))
+	}
+	if j.cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(j.cfg.Audience))
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		return j.key(r.Context(), t)
+	}, opts...); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	uid, _ := claims[j.cfg.UserClaim].(string)
+	if uid == "" {
+		return nil, fmt.Errorf("%w: claim %q is missing", ErrUnauthenticated, j.cfg.UserClaim)
+	}
+	p := &Principal{Subject: uid, ID: "jwt:" + uid, Role: j.cfg.Role}
+	if j.cfg.TeamClaim != "" {
+		// пустая команда означает доступ ко всем — без claim токен не принимаем
+		p.Team, _ = claims[j.cfg.TeamClaim].(string)
+		if p.Team == "" {
+			return nil, fmt.Errorf("%w: claim %q is missing", ErrUnauthenticated, j.cfg.TeamClaim)
+		}
+	}
+	return p, nil
+}
+
+// key подбирает ключ по kid; неизвестный kid перечитывает JWKS (не чаще RefreshInterval)
+func (j *JWT) key(ctx context.Context, t *jwt.Token) (crypto.PublicKey, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	j.mu.RLock()
+	k, ok := j.keys[kid]
+	stale := time.Since(j.fetchedAt) >= j.cfg.RefreshInterval
+	j.mu.RUnlock()
+	if ok {
+		return k, nil
+	}
+	if stale {
+		if err := j.refresh(ctx); err != nil {
+			return nil, err
+		}
+		j.mu.RLock()
+		k, ok = j.keys[kid]
+		j.mu.RUnlock()
+		if ok {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func (j *JWT) refresh(ctx context.Context) error {
+	data, err := j.readJWKS(ctx)
+	if err != nil {
+		return fmt.Errorf("auth: read JWKS: %w", err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	j.keys, j.fetchedAt = keys, time.Now()
+	j.mu.Unlock()
+	return nil
+}
+
+func (j *JWT) readJWKS(ctx context.Context) ([]byte, error) {
+	src := j.cfg.JWKS
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		return os.ReadFile(src)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// jwk — поля JSON Web Key, нужные для RSA и EC ключей
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS разбирает JWKS-документ в набор публичных ключей по kid.
+// Ключи не для подписи и неподдерживаемых типов пропускаются
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("auth: parse JWKS: %w", err)
+	}
+	out := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			pub crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			pub, err = k.rsa()
+		case "EC":
+			pub, err = k.ecdsa()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("auth: JWKS key %q: %w", k.Kid, err)
+		}
+		out[k.Kid] = pub
+	}
+	if len(out) == 0 {
+		return nil, errors.New("auth: JWKS has no usable signing keys")
+	}
+	return out, nil
+}
+
+func (k jwk) rsa() (*rsa.PublicKey, error) {
+	n, err := b64int(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := b64int(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() {
+		return nil, errors.New("exponent is too large")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecdsa() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := b64int(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := b64int(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func b64int(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// Chain пробует аутентификаторы по очереди и возвращает первый успешный результат
+type Chain []Authenticator
+
+// Authenticate реализует Authenticator
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, a := range c {
+		if p, err := a.Authenticate(r); err == nil {
+			return p, nil
+		}
+	}
+	return nil, ErrUnauthenticated
+}
@@ -0,0 +1,150 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/alinaaved/pr-reviewer/internal/auth"
+)
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// writeJWKS сохраняет публичные ключи в JWKS-файл и возвращает путь к нему
+func writeJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) string {
+	t.Helper()
+	set := map[string]any{"keys": []map[string]string{
+		{
+			"kid": "rsa-1", "kty": "RSA", "use": "sig",
+			"n": b64(rsaKey.N.Bytes()),
+			"e": b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+		},
+		{
+			"kid": "ec-1", "kty": "EC", "crv": "P-256",
+			"x": b64(ecKey.X.FillBytes(make([]byte, 32))),
+			"y": b64(ecKey.Y.FillBytes(make([]byte, 32))),
+		},
+	}}
+	b, _ := json.Marshal(set)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+	return path
+}
+
+func sign(t *testing.T, m jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(m, claims)
+	tok.Header["kid"] = kid
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return s
+}
+
+func TestJWTAuthenticate(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ec key: %v", err)
+	}
+	v, err := auth.NewJWT(context.Background(), auth.JWTConfig{
+		JWKS:      writeJWKS(t, rsaKey, ecKey),
+		Issuer:    "https://sso.example.com",
+		Audience:  "pr-reviewer",
+		UserClaim: "preferred_username",
+		TeamClaim: "team",
+	})
+	if err != nil {
+		t.Fatalf("new jwt: %v", err)
+	}
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":                "https://sso.example.com",
+			"aud":                "pr-reviewer",
+			"sub":                "0f8a",
+			"preferred_username": "u2",
+			"team":               "backend",
+			"exp":                time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	authenticate := func(tok string) (*auth.Principal, error) {
+		r := httptest.NewRequest("POST", "/pullRequest/reassign", nil)
+		r.Header.Set("Authorization", "Bearer "+tok)
+		return v.Authenticate(r)
+	}
+
+	for _, tc := range []struct {
+		name string
+		m    jwt.SigningMethod
+		kid  string
+		key  any
+	}{
+		{"RS256", jwt.SigningMethodRS256, "rsa-1", rsaKey},
+		{"ES256", jwt.SigningMethodES256, "ec-1", ecKey},
+	} {
+		p, err := authenticate(sign(t, tc.m, tc.kid, tc.key, valid()))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if p.Subject != "u2" || p.UserID() != "u2" || p.Team != "backend" || p.Role != auth.RoleWriter {
+			t.Fatalf("%s: unexpected principal %+v", tc.name, p)
+		}
+	}
+
+	expired := valid()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	if _, err := authenticate(sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, expired)); err == nil {
+		t.Fatalf("expired token accepted")
+	}
+
+	foreign := valid()
+	foreign["aud"] = "other-service"
+	if _, err := authenticate(sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, foreign)); err == nil {
+		t.Fatalf("token for another audience accepted")
+	}
+
+	noUser := valid()
+	delete(noUser, "preferred_username")
+	if _, err := authenticate(sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, noUser)); err == nil {
+		t.Fatalf("token without user claim accepted")
+	}
+
+	noTeam := valid()
+	delete(noTeam, "team")
+	if _, err := authenticate(sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, noTeam)); err == nil {
+		t.Fatalf("token without team claim accepted")
+	}
+	badTeam := valid()
+	badTeam["team"] = 42
+	if _, err := authenticate(sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, badTeam)); err == nil {
+		t.Fatalf("token with non-string team claim accepted")
+	}
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := authenticate(sign(t, jwt.SigningMethodRS256, "rsa-1", otherKey, valid())); err == nil {
+		t.Fatalf("token signed by unknown key accepted")
+	}
+
+	if _, err := authenticate(sign(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret"), valid())); err == nil {
+		t.Fatalf("HS256 token accepted")
+	}
+}
@@ -0,0 +1,342 @@
+// Package client — HTTP-клиент API сервиса (используется prrctl)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	httpapi "github.com/alinaaved/pr-reviewer/internal/http"
+)
+
+// Client вызывает API сервиса
+type Client struct {
+	BaseURL string // например http://localhost:8080
+	Token   string // Bearer-токен (пусто — без Authorization)
+	HTTP    *http.Client
+}
+
+// New создаёт клиент с таймаутом по умолчанию
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// APIError — ответ сервиса с кодом ошибки
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("http %d: %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("http %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// ReassignResult — ответ /pullRequest/reassign
+type ReassignResult struct {
+	PR         httpapi.PullRequest `json:"pr"`
+	ReplacedBy string              `json:"replaced_by"`
+}
+
+// UserReviews — ответ /users/getReview
+type UserReviews struct {
+	UserID       string               `json:"user_id"`
+	PullRequests []httpapi.ReviewItem `json:"pull_requests"`
+	NextCursor   string               `json:"next_cursor,omitempty"`
+}
+
+// TeamAdd создаёт команду и участников
+func (c *Client) TeamAdd(ctx context.Context, t httpapi.Team) (httpapi.Team, error) {
+	var out struct {
+		Team httpapi.Team `json:"team"`
+	}
+	err := c.do(ctx, http.MethodPost, "/team/add", nil, t, &out)
+	return out.Team, err
+}
+
+// TeamGet возвращает команду с участниками
+func (c *Client) TeamGet(ctx context.Context, name string) (httpapi.Team, error) {
+	var out httpapi.Team
+	err := c.do(ctx, http.MethodGet, "/team/get", url.Values{"team_name": {name}}, nil, &out)
+	return out, err
+}
+
+// SetIsActive переключает активность пользователя
+func (c *Client) SetIsActive(ctx context.Context, userID string, active bool) (httpapi.User, error) {
+	var out struct {
+		User httpapi.User `json:"user"`
+	}
+	in := map[string]any{"user_id": userID, "is_active": active}
+	err := c.do(ctx, http.MethodPost, "/users/setIsActive", nil, in, &out)
+	return out.User, err
+}
+
+// SetLevel меняет уровень пользователя (junior, mid, senior)
+func (c *Client) SetLevel(ctx context.Context, userID, level string) (httpapi.User, error) {
+	var out struct {
+		User httpapi.User `json:"user"`
+	}
+	in := map[string]string{"user_id": userID, "level": level}
+	err := c.do(ctx, http.MethodPost, "/users/setLevel", nil, in, &out)
+	return out.User, err
+}
+
+// SetComposition задаёт, сколько senior нужно среди ревьюверов PR команды
+func (c *Client) SetComposition(ctx context.Context, team string, minSeniors int) (httpapi.Team, error) {
+	var out httpapi.Team
+	in := httpapi.Team{TeamName: team, MinSeniorReviewers: minSeniors}
+	err := c.do(ctx, http.MethodPost, "/team/setComposition", nil, in, &out)
+	return out, err
+}
+
+// SetCapacity задаёт пользователю лимит открытых ревью; nil — лимит команды
+func (c *Client) SetCapacity(ctx context.Context, userID string, limit *int) (httpapi.User, error) {
+	var out struct {
+		User httpapi.User `json:"user"`
+	}
+	in := map[string]any{"user_id": userID, "max_open_reviews": limit}
+	err := c.do(ctx, http.MethodPost, "/users/setCapacity", nil, in, &out)
+	return out.User, err
+}
+
+// SetTeamCapacity задаёт лимит открытых ревью по умолчанию для участников команды; nil — без ограничения
+func (c *Client) SetTeamCapacity(ctx context.Context, team string, limit *int) (httpapi.Team, error) {
+	var out httpapi.Team
+	in := map[string]any{"team_name": team, "max_open_reviews": limit}
+	err := c.do(ctx, http.MethodPost, "/team/setCapacity", nil, in, &out)
+	return out, err
+}
+
+// GetReview возвращает PR со статусом status (OPEN, MERGED, ALL; пусто — OPEN),
+// где пользователь назначен ревьювером, вместе с возрастом ревью.
+// Проходит по всем страницам
+func (c *Client) GetReview(ctx context.Context, userID, status string) (UserReviews, error) {
+	out := UserReviews{UserID: userID}
+	q := url.Values{"user_id": {userID}, "include": {"age"}}
+	if status != "" {
+		q.Set("status", status)
+	}
+	for {
+		var page UserReviews
+		if err := c.do(ctx, http.MethodGet, "/users/getReview", q, nil, &page); err != nil {
+			return out, err
+		}
+		out.PullRequests = append(out.PullRequests, page.PullRequests...)
+		if page.NextCursor == "" {
+			return out, nil
+		}
+		q.Set("cursor", page.NextCursor)
+	}
+}
+
+// GetPR возвращает PR с данными ревьюверов и историей
+func (c *Client) GetPR(ctx context.Context, prID string) (httpapi.PullRequestDetail, error) {
+	var out struct {
+		PR httpapi.PullRequestDetail `json:"pr"`
+	}
+	err := c.do(ctx, http.MethodGet, "/pullRequest/get", url.Values{"pull_request_id": {prID}}, nil, &out)
+	return out.PR, err
+}
+
+// CreateBatch создаёт PR одной транзакцией; ошибки отдельных PR — в результатах
+func (c *Client) CreateBatch(ctx context.Context, prs []httpapi.NewPR) (httpapi.BatchCreateResult, error) {
+	var out httpapi.BatchCreateResult
+	err := c.do(ctx, http.MethodPost, "/pullRequest/createBatch", nil, httpapi.BatchCreateRequest{PullRequests: prs}, &out)
+	return out, err
+}
+
+// ImportPRs загружает исторические PR из NDJSON (batchSize <= 0 — размер пачки по умолчанию)
+func (c *Client) ImportPRs(ctx context.Context, ndjson io.Reader, batchSize int) (httpapi.ImportResult, error) {
+	var out httpapi.ImportResult
+	var q url.Values
+	if batchSize > 0 {
+		q = url.Values{"batch_size": {strconv.Itoa(batchSize)}}
+	}
+	err := c.do(ctx, http.MethodPost, "/pullRequest/import", q, ndjson, &out)
+	return out, err
+}
+
+// Merge помечает PR как MERGED
+func (c *Client) Merge(ctx context.Context, prID string) (httpapi.PullRequest, error) {
+	var out struct {
+		PR httpapi.PullRequest `json:"pr"`
+	}
+	err := c.do(ctx, http.MethodPost, "/pullRequest/merge", nil, map[string]string{"pull_request_id": prID}, &out)
+	return out.PR, err
+}
+
+// Reassign заменяет ревьювера oldUserID на PR
+func (c *Client) Reassign(ctx context.Context, prID, oldUserID string) (ReassignResult, error) {
+	var out ReassignResult
+	in := map[string]string{"pull_request_id": prID, "old_user_id": oldUserID}
+	err := c.do(ctx, http.MethodPost, "/pullRequest/reassign", nil, in, &out)
+	return out, err
+}
+
+// Decline — отказ ревьювера userID от ревью PR с причиной reason
+// (BUSY, LACKS_CONTEXT, CONFLICT_OF_INTEREST); пустой userID — вызывающий
+func (c *Client) Decline(ctx context.Context, prID, userID, reason string) (httpapi.DeclineResult, error) {
+	var out httpapi.DeclineResult
+	in := map[string]string{"pull_request_id": prID, "user_id": userID, "reason": reason}
+	err := c.do(ctx, http.MethodPost, "/pullRequest/decline", nil, in, &out)
+	return out, err
+}
+
+// SetReviewers закрепляет за PR ревьюверов userIDs; остальные слоты заполняет сервер
+func (c *Client) SetReviewers(ctx context.Context, prID string, userIDs []string) (httpapi.PullRequest, error) {
+	var out struct {
+		PR httpapi.PullRequest `json:"pr"`
+	}
+	in := map[string]any{"pull_request_id": prID, "reviewers": userIDs}
+	err := c.do(ctx, http.MethodPost, "/pullRequest/setReviewers", nil, in, &out)
+	return out.PR, err
+}
+
+// AddRule создаёт правило назначения для пары автор/ревьювер
+func (c *Client) AddRule(ctx context.Context, rule httpapi.ReviewerRule) (httpapi.ReviewerRule, error) {
+	var out struct {
+		Rule httpapi.ReviewerRule `json:"rule"`
+	}
+	err := c.do(ctx, http.MethodPost, "/rules/add", nil, rule, &out)
+	return out.Rule, err
+}
+
+// ListRules возвращает правила назначения (фильтры необязательны)
+func (c *Client) ListRules(ctx context.Context, authorID, team string) ([]httpapi.ReviewerRule, error) {
+	q := url.Values{}
+	if authorID != "" {
+		q.Set("author_id", authorID)
+	}
+	if team != "" {
+		q.Set("team_name", team)
+	}
+	var out struct {
+		Rules []httpapi.ReviewerRule `json:"rules"`
+	}
+	err := c.do(ctx, http.MethodGet, "/rules/list", q, nil, &out)
+	return out.Rules, err
+}
+
+// DeleteRule удаляет правило назначения
+func (c *Client) DeleteRule(ctx context.Context, id int64) (httpapi.ReviewerRule, error) {
+	var out struct {
+		Rule httpapi.ReviewerRule `json:"rule"`
+	}
+	err := c.do(ctx, http.MethodPost, "/rules/delete", nil, map[string]int64{"id": id}, &out)
+	return out.Rule, err
+}
+
+// RosterResult — ответ /team/sync
+type RosterResult struct {
+	DryRun bool               `json:"dry_run"`
+	Diff   httpapi.RosterDiff `json:"diff"`
+}
+
+// RosterSync приводит команды к ростеру (dryRun — только посчитать изменения)
+func (c *Client) RosterSync(ctx context.Context, teams []httpapi.Team, dryRun bool) (RosterResult, error) {
+	var out RosterResult
+	in := httpapi.RosterSyncRequest{Teams: teams, DryRun: dryRun}
+	err := c.do(ctx, http.MethodPost, "/team/sync", nil, in, &out)
+	return out, err
+}
+
+// AssignmentsByUser возвращает число назначений по пользователям
+func (c *Client) AssignmentsByUser(ctx context.Context) ([]httpapi.AssignmentCount, error) {
+	var out struct {
+		Items []httpapi.AssignmentCount `json:"items"`
+	}
+	err := c.do(ctx, http.MethodGet, "/stats/assignments-by-user", nil, nil, &out)
+	return out.Items, err
+}
+
+// Fairness возвращает отчёт о распределении ревью в команде за окно [from, to)
+// (пустые from/to — окно по умолчанию на сервере)
+func (c *Client) Fairness(ctx context.Context, team, from, to string) (httpapi.FairnessReport, error) {
+	var out httpapi.FairnessReport
+	q := url.Values{"team_name": {team}}
+	if from != "" {
+		q.Set("from", from)
+	}
+	if to != "" {
+		q.Set("to", to)
+	}
+	err := c.do(ctx, http.MethodGet, "/stats/fairness", q, nil, &out)
+	return out, err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, q url.Values, in, out any) error {
+	u := c.BaseURL + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	// io.Reader уходит как есть (NDJSON), остальное — JSON
+	var body io.Reader
+	ctype := "application/json"
+	switch v := in.(type) {
+	case nil:
+	case io.Reader:
+		body, ctype = v, "application/x-ndjson"
+	default:
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", ctype)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return decodeError(resp.StatusCode, data)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// decodeError разбирает ErrorResponse; у некоторых ответов только {"message": ...}
+func decodeError(status int, data []byte) error {
+	var er httpapi.ErrorResponse
+	if json.Unmarshal(data, &er) == nil && er.Error.Code != "" {
+		return &APIError{Status: status, Code: er.Error.Code, Message: er.Error.Message}
+	}
+	var plain struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &plain) == nil && plain.Message != "" {
+		return &APIError{Status: status, Message: plain.Message}
+	}
+	return &APIError{Status: status, Message: strings.TrimSpace(string(data))}
+}
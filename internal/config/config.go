@@ -0,0 +1,308 @@
+// Package config — типизированная конфигурация сервиса: файл YAML/TOML + переопределения из env
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/alinaaved/pr-reviewer/internal/auth"
+	"github.com/alinaaved/pr-reviewer/internal/selector"
+)
+
+// Config — настройки сервиса
+type Config struct {
+	Server      Server      `yaml:"server" toml:"server"`
+	DB          DB          `yaml:"db" toml:"db"`
+	Log         Log         `yaml:"log" toml:"log"`
+	Auth        Auth        `yaml:"auth" toml:"auth"`
+	Selector    Selector    `yaml:"selector" toml:"selector"`
+	Idempotency Idempotency `yaml:"idempotency" toml:"idempotency"`
+}
+
+// Server — HTTP-сервер
+type Server struct {
+	Addr              string        `yaml:"addr" toml:"addr"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+// DB — подключение к PostgreSQL и пул соединений
+type DB struct {
+	DSN             string        `yaml:"dsn" toml:"dsn"`
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout" toml:"connect_timeout"`
+	SlowQuery       time.Duration `yaml:"slow_query" toml:"slow_query"`
+}
+
+// Log — логирование
+type Log struct {
+	Level string `yaml:"level" toml:"level"` // debug | info | warn | error
+}
+
+// Auth — аутентификация; пустые Tokens и JWT.JWKS — аутентификация выключена
+type Auth struct {
+	Tokens string `yaml:"tokens" toml:"tokens"` // "<токен>=<роль>[:<команда>],..."
+	JWT    JWT    `yaml:"jwt" toml:"jwt"`
+}
+
+// JWT — проверка JWT от OIDC-провайдера
+type JWT struct {
+	JWKS      string `yaml:"jwks" toml:"jwks"`
+	Issuer    string `yaml:"issuer" toml:"issuer"`
+	Audience  string `yaml:"audience" toml:"audience"`
+	UserClaim string `yaml:"user_claim" toml:"user_claim"`
+	TeamClaim string `yaml:"team_claim" toml:"team_claim"`
+}
+
+// Selector — выбор ревьюверов
+type Selector struct {
+	Strategy  string `yaml:"strategy" toml:"strategy"`   // random | least_loaded
+	Reviewers int    `yaml:"reviewers" toml:"reviewers"` // ревьюверов на PR, 0..2
+	Seed      int64  `yaml:"seed" toml:"seed"`           // зерно случайного выбора; 0 — от времени
+	// TestMode разрешает задавать зерно на запрос заголовком X-Selector-Seed — только для тестов
+	TestMode bool `yaml:"test_mode" toml:"test_mode"`
+}
+
+// Idempotency — хранение ответов для повторов с Idempotency-Key
+type Idempotency struct {
+	TTL time.Duration `yaml:"ttl" toml:"ttl"` // сколько хранить ответ
+}
+
+// Default возвращает конфигурацию по умолчанию (без DSN — его задают явно)
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       10 * time.Second,
+			WriteTimeout:      15 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   10 * time.Second,
+		},
+		DB: DB{
+			MaxOpenConns:    20,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectTimeout:  time.Minute,
+			SlowQuery:       200 * time.Millisecond,
+		},
+		Log:         Log{Level: "info"},
+		Selector:    Selector{Strategy: selector.StrategyRandom, Reviewers: 2},
+		Idempotency: Idempotency{TTL: 24 * time.Hour},
+	}
+}
+
+// Load читает конфигурацию: значения по умолчанию, затем файл path (если задан;
+// формат по расширению .yaml/.yml/.toml), затем переменные окружения. Результат валидируется
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := readFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := applyEnv(&cfg, os.LookupEnv); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func readFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(strings.NewReader(string(data)))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil {
+			return fmt.Errorf("config: %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("config: %s: %w", path, err)
+		}
+		if und := md.Undecoded(); len(und) > 0 {
+			return fmt.Errorf("config: %s: unknown keys %v", path, und)
+		}
+	default:
+		return fmt.Errorf("config: %s: unsupported format %q (want .yaml, .yml or .toml)", path, ext)
+	}
+	return nil
+}
+
+// envVar — переменная окружения и поле конфигурации, которое она переопределяет
+type envVar struct {
+	name string
+	set  func(cfg *Config, v string) error
+}
+
+func str(f func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error { *f(c) = v; return nil }
+}
+
+func num(f func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*f(c) = n
+		return nil
+	}
+}
+
+func num64(f func(*Config) *int64) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		*f(c) = n
+		return nil
+	}
+}
+
+func boolean(f func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*f(c) = b
+		return nil
+	}
+}
+
+func dur(f func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*f(c) = d
+		return nil
+	}
+}
+
+// envVars — поддерживаемые переопределения; имена прежних переменных сохранены
+var envVars = []envVar{
+	{"APP_PORT", str(func(c *Config) *string { return &c.Server.Addr })},
+	{"HTTP_READ_HEADER_TIMEOUT", dur(func(c *Config) *time.Duration { return &c.Server.ReadHeaderTimeout })},
+	{"HTTP_READ_TIMEOUT", dur(func(c *Config) *time.Duration { return &c.Server.ReadTimeout })},
+	{"HTTP_WRITE_TIMEOUT", dur(func(c *Config) *time.Duration { return &c.Server.WriteTimeout })},
+	{"HTTP_IDLE_TIMEOUT", dur(func(c *Config) *time.Duration { return &c.Server.IdleTimeout })},
+	{"HTTP_SHUTDOWN_TIMEOUT", dur(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+	{"DB_DSN", str(func(c *Config) *string { return &c.DB.DSN })},
+	{"DB_MAX_OPEN_CONNS", num(func(c *Config) *int { return &c.DB.MaxOpenConns })},
+	{"DB_MAX_IDLE_CONNS", num(func(c *Config) *int { return &c.DB.MaxIdleConns })},
+	{"DB_CONN_MAX_LIFETIME", dur(func(c *Config) *time.Duration { return &c.DB.ConnMaxLifetime })},
+	{"DB_CONN_MAX_IDLE_TIME", dur(func(c *Config) *time.Duration { return &c.DB.ConnMaxIdleTime })},
+	{"DB_CONNECT_TIMEOUT", dur(func(c *Config) *time.Duration { return &c.DB.ConnectTimeout })},
+	{"DB_SLOW_QUERY", dur(func(c *Config) *time.Duration { return &c.DB.SlowQuery })},
+	{"LOG_LEVEL", str(func(c *Config) *string { return &c.Log.Level })},
+	{"AUTH_TOKENS", str(func(c *Config) *string { return &c.Auth.Tokens })},
+	{"AUTH_JWKS", str(func(c *Config) *string { return &c.Auth.JWT.JWKS })},
+	{"AUTH_JWT_ISSUER", str(func(c *Config) *string { return &c.Auth.JWT.Issuer })},
+	{"AUTH_JWT_AUDIENCE", str(func(c *Config) *string { return &c.Auth.JWT.Audience })},
+	{"AUTH_JWT_USER_CLAIM", str(func(c *Config) *string { return &c.Auth.JWT.UserClaim })},
+	{"AUTH_JWT_TEAM_CLAIM", str(func(c *Config) *string { return &c.Auth.JWT.TeamClaim })},
+	{"SELECTOR_STRATEGY", str(func(c *Config) *string { return &c.Selector.Strategy })},
+	{"SELECTOR_REVIEWERS", num(func(c *Config) *int { return &c.Selector.Reviewers })},
+	{"SELECTOR_SEED", num64(func(c *Config) *int64 { return &c.Selector.Seed })},
+	{"SELECTOR_TEST_MODE", boolean(func(c *Config) *bool { return &c.Selector.TestMode })},
+	{"IDEMPOTENCY_TTL", dur(func(c *Config) *time.Duration { return &c.Idempotency.TTL })},
+}
+
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	var errs []error
+	for _, e := range envVars {
+		v, ok := lookup(e.name)
+		if !ok || v == "" {
+			continue
+		}
+		if err := e.set(cfg, v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки разом
+func (c Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) { errs = append(errs, fmt.Errorf(format, args...)) }
+
+	if c.Server.Addr == "" {
+		fail("server.addr is required")
+	}
+	for _, d := range []struct {
+		name string
+		v    time.Duration
+	}{
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+		{"db.connect_timeout", c.DB.ConnectTimeout},
+		{"idempotency.ttl", c.Idempotency.TTL},
+	} {
+		if d.v <= 0 {
+			fail("%s must be positive, got %s", d.name, d.v)
+		}
+	}
+	if c.DB.DSN == "" {
+		fail("db.dsn is required (set it in the config file or DB_DSN)")
+	}
+	if c.DB.MaxOpenConns < 1 {
+		fail("db.max_open_conns must be >= 1, got %d", c.DB.MaxOpenConns)
+	}
+	if c.DB.MaxIdleConns < 0 || c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		fail("db.max_idle_conns must be in 0..db.max_open_conns, got %d", c.DB.MaxIdleConns)
+	}
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		fail("log.level must be one of debug|info|warn|error, got %q", c.Log.Level)
+	}
+	if c.Auth.Tokens != "" {
+		if _, err := auth.ParseStaticTokens(c.Auth.Tokens); err != nil {
+			fail("auth.tokens: %v", err)
+		}
+	}
+	if _, err := selector.New(c.Selector.Strategy); err != nil {
+		fail("selector.strategy must be one of %s|%s, got %q",
+			selector.StrategyRandom, selector.StrategyLeastLoaded, c.Selector.Strategy)
+	}
+	if c.Selector.Reviewers < 0 || c.Selector.Reviewers > 2 {
+		fail("selector.reviewers must be in 0..2, got %d", c.Selector.Reviewers)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("config: invalid: %w", errors.Join(errs...))
+	}
+	return nil
+}
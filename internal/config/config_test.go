@@ -0,0 +1,122 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoad_YAMLAndTOML(t *testing.T) {
+	t.Setenv("APP_PORT", "")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "")
+	yamlPath := writeFile(t, "app.yaml", `
+server:
+  addr: ":9090"
+  write_timeout: 30s
+db:
+  dsn: postgres://u:p@db:5432/app
+  max_open_conns: 50
+selector:
+  strategy: least_loaded
+  reviewers: 1
+`)
+	tomlPath := writeFile(t, "app.toml", `
+[server]
+addr = ":9090"
+write_timeout = "30s"
+
+[db]
+dsn = "postgres://u:p@db:5432/app"
+max_open_conns = 50
+
+[selector]
+strategy = "least_loaded"
+reviewers = 1
+`)
+	for _, path := range []string{yamlPath, tomlPath} {
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if cfg.Server.Addr != ":9090" || cfg.Server.WriteTimeout != 30*time.Second {
+			t.Fatalf("%s: server=%+v", path, cfg.Server)
+		}
+		if cfg.DB.MaxOpenConns != 50 || cfg.DB.MaxIdleConns != 5 {
+			t.Fatalf("%s: db=%+v", path, cfg.DB)
+		}
+		// не заданное в файле берётся из значений по умолчанию
+		if cfg.Server.ReadTimeout != 10*time.Second {
+			t.Fatalf("%s: read_timeout=%s", path, cfg.Server.ReadTimeout)
+		}
+		if cfg.Selector.Strategy != "least_loaded" || cfg.Selector.Reviewers != 1 {
+			t.Fatalf("%s: selector=%+v", path, cfg.Selector)
+		}
+	}
+}
+
+func TestLoad_EnvOverridesFile(t *testing.T) {
+	path := writeFile(t, "app.yml", "db:\n  dsn: postgres://from-file\n")
+	t.Setenv("DB_DSN", "postgres://from-env")
+	t.Setenv("APP_PORT", ":7070")
+	t.Setenv("DB_CONNECT_TIMEOUT", "5s")
+	t.Setenv("IDEMPOTENCY_TTL", "1h")
+	t.Setenv("SELECTOR_SEED", "42")
+	t.Setenv("SELECTOR_TEST_MODE", "true")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.DB.DSN != "postgres://from-env" || cfg.Server.Addr != ":7070" || cfg.DB.ConnectTimeout != 5*time.Second ||
+		cfg.Idempotency.TTL != time.Hour || cfg.Selector.Seed != 42 || !cfg.Selector.TestMode {
+		t.Fatalf("env not applied: %+v", cfg)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Setenv("DB_DSN", "")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "db.dsn is required") {
+		t.Fatalf("missing DSN: err=%v", err)
+	}
+
+	path := writeFile(t, "app.yaml", "db:\n  dns: typo\n")
+	if _, err := Load(path); err == nil {
+		t.Fatalf("unknown key accepted")
+	}
+
+	t.Setenv("DB_DSN", "postgres://x")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "soon")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "HTTP_WRITE_TIMEOUT") {
+		t.Fatalf("bad duration: err=%v", err)
+	}
+}
+
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	cfg := Default()
+	cfg.DB.DSN = "postgres://x"
+	cfg.Selector.Strategy = "round_robin"
+	cfg.Selector.Reviewers = 3
+	cfg.Log.Level = "verbose"
+	cfg.Server.ReadTimeout = 0
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatalf("invalid config accepted")
+	}
+	for _, want := range []string{"selector.strategy", "selector.reviewers", "log.level", "server.read_timeout"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error has no %q: %v", want, err)
+		}
+	}
+}
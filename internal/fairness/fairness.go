@@ -0,0 +1,144 @@
+// Package fairness считает, насколько равномерно распределены ревью в команде
+// с поправкой на время, когда участник был активен
+package fairness
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultOutlierRatio — во сколько раз доля назначений должна отличаться
+// от доли активного времени, чтобы участник считался выбросом
+const DefaultOutlierRatio = 1.5
+
+// Выбросы
+const (
+	Overloaded  = "over"
+	Underloaded = "under"
+)
+
+// Change — смена активности пользователя
+type Change struct {
+	At     time.Time
+	Active bool
+}
+
+// ActiveTime возвращает, сколько времени из окна [from, to) пользователь был активен.
+// changes — по возрастанию времени; до первой смены пользователь считается неактивным
+func ActiveTime(changes []Change, from, to time.Time) time.Duration {
+	var total time.Duration
+	active := false
+	since := from
+	for _, c := range changes {
+		if !c.At.After(from) {
+			active = c.Active
+			continue
+		}
+		if !c.At.Before(to) {
+			break
+		}
+		if active {
+			total += c.At.Sub(since)
+		}
+		active, since = c.Active, c.At
+	}
+	if active {
+		total += to.Sub(since)
+	}
+	return total
+}
+
+// Member — входные данные по участнику
+type Member struct {
+	UserID      string
+	Active      time.Duration
+	Assignments int64
+}
+
+// Row — участник в отчёте
+type Row struct {
+	Member
+	ActiveShare     float64 // доля активного времени участника в команде
+	AssignmentShare float64 // доля назначений участника в команде
+	LoadRatio       float64 // AssignmentShare / ActiveShare; 1 — ровно «своя» доля
+	Outlier         string  // Overloaded | Underloaded | ""
+}
+
+// Report — отчёт по команде
+type Report struct {
+	TotalAssignments int64
+	// Gini — коэффициент Джини по назначениям на единицу активного времени:
+	// 0 — нагрузка пропорциональна активности, ближе к 1 — всё достаётся одному
+	Gini    float64
+	Members []Row
+}
+
+// Build строит отчёт. outlierRatio <= 1 заменяется на DefaultOutlierRatio.
+// Участник с назначениями, но без активного времени, всегда Overloaded
+func Build(members []Member, outlierRatio float64) Report {
+	if outlierRatio <= 1 {
+		outlierRatio = DefaultOutlierRatio
+	}
+	var rep Report
+	var totalActive time.Duration
+	for _, m := range members {
+		rep.TotalAssignments += m.Assignments
+		totalActive += m.Active
+	}
+
+	rates := make([]float64, 0, len(members))
+	for _, m := range members {
+		row := Row{Member: m}
+		if totalActive > 0 {
+			row.ActiveShare = float64(m.Active) / float64(totalActive)
+		}
+		if rep.TotalAssignments > 0 {
+			row.AssignmentShare = float64(m.Assignments) / float64(rep.TotalAssignments)
+		}
+		switch {
+		case row.ActiveShare > 0:
+			row.LoadRatio = row.AssignmentShare / row.ActiveShare
+			rates = append(rates, float64(m.Assignments)/m.Active.Hours())
+			if rep.TotalAssignments > 0 {
+				if row.LoadRatio > outlierRatio {
+					row.Outlier = Overloaded
+				} else if row.LoadRatio < 1/outlierRatio {
+					row.Outlier = Underloaded
+				}
+			}
+		case m.Assignments > 0:
+			row.Outlier = Overloaded
+		}
+		rep.Members = append(rep.Members, row)
+	}
+	rep.Gini = Gini(rates)
+
+	sort.SliceStable(rep.Members, func(i, j int) bool {
+		if rep.Members[i].LoadRatio != rep.Members[j].LoadRatio {
+			return rep.Members[i].LoadRatio > rep.Members[j].LoadRatio
+		}
+		return rep.Members[i].UserID < rep.Members[j].UserID
+	})
+	return rep
+}
+
+// Gini — коэффициент Джини для неотрицательных значений (0, если значений нет или все нули)
+func Gini(xs []float64) float64 {
+	n := len(xs)
+	if n == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	var sum, weighted float64
+	for i, x := range sorted {
+		sum += x
+		weighted += float64(i+1) * x
+	}
+	if sum == 0 {
+		return 0
+	}
+	g := (2*weighted)/(float64(n)*sum) - float64(n+1)/float64(n)
+	return math.Max(0, g)
+}
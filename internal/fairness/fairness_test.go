@@ -0,0 +1,74 @@
+package fairness_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/alinaaved/pr-reviewer/internal/fairness"
+)
+
+func TestActiveTime(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(10 * 24 * time.Hour)
+	day := func(d int) time.Time { return from.Add(time.Duration(d) * 24 * time.Hour) }
+
+	cases := []struct {
+		name    string
+		changes []fairness.Change
+		want    time.Duration
+	}{
+		{"no history", nil, 0},
+		{"active before window", []fairness.Change{{day(-5), true}}, 10 * 24 * time.Hour},
+		{"created mid-window", []fairness.Change{{day(4), true}}, 6 * 24 * time.Hour},
+		{"vacation", []fairness.Change{{day(-1), true}, {day(2), false}, {day(5), true}}, 7 * 24 * time.Hour},
+		{"deactivated after window", []fairness.Change{{day(-1), true}, {day(12), false}}, 10 * 24 * time.Hour},
+		{"inactive all along", []fairness.Change{{day(-1), true}, {day(0), false}}, 0},
+	}
+	for _, c := range cases {
+		if got := fairness.ActiveTime(c.changes, from, to); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestGini(t *testing.T) {
+	if g := fairness.Gini([]float64{3, 3, 3}); g != 0 {
+		t.Fatalf("equal: %v", g)
+	}
+	if g := fairness.Gini([]float64{0, 0, 0, 12}); math.Abs(g-0.75) > 1e-9 {
+		t.Fatalf("one takes all of 4: %v (want 0.75)", g)
+	}
+	if g := fairness.Gini(nil); g != 0 {
+		t.Fatalf("empty: %v", g)
+	}
+}
+
+func TestBuild_AdjustsForActiveTime(t *testing.T) {
+	week := 7 * 24 * time.Hour
+	rep := fairness.Build([]fairness.Member{
+		{UserID: "u1", Active: week, Assignments: 10},
+		{UserID: "u2", Active: week, Assignments: 2},
+		// полнедели в отпуске — половина нагрузки это честно
+		{UserID: "u3", Active: week / 2, Assignments: 3},
+		{UserID: "u4", Active: 0, Assignments: 0},
+	}, 0)
+
+	if rep.TotalAssignments != 15 {
+		t.Fatalf("total: %d", rep.TotalAssignments)
+	}
+	byID := map[string]fairness.Row{}
+	for _, r := range rep.Members {
+		byID[r.UserID] = r
+	}
+	if byID["u1"].Outlier != fairness.Overloaded || byID["u2"].Outlier != fairness.Underloaded ||
+		byID["u3"].Outlier != "" || byID["u4"].Outlier != "" {
+		t.Fatalf("outliers: %+v", rep.Members)
+	}
+	if rep.Members[0].UserID != "u1" {
+		t.Fatalf("most loaded first: %+v", rep.Members)
+	}
+	if rep.Gini <= 0 || rep.Gini >= 1 {
+		t.Fatalf("gini: %v", rep.Gini)
+	}
+}
@@ -0,0 +1,64 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/auth"
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+// Authenticate — middleware аутентификации; без настроенного Authenticator пропускает всё
+func (h *Handler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.auth == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		p, err := h.auth.Authenticate(r)
+		if err != nil {
+			writeErr(w, "UNAUTHORIZED", "missing or invalid credentials", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+	})
+}
+
+// allowTeam проверяет право вызывающего изменять данные команды team.
+// При отказе сам пишет 403 и возвращает false
+func (h *Handler) allowTeam(w http.ResponseWriter, r *http.Request, team string) bool {
+	if msg := teamDenied(r, team); msg != "" {
+		writeErr(w, "FORBIDDEN", msg, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// teamDenied — причина отказа в изменении данных команды team или "", если можно
+func teamDenied(r *http.Request, team string) string {
+	p := auth.FromContext(r.Context())
+	switch {
+	case p == nil:
+		return ""
+	case !p.CanWrite():
+		return "token is read-only"
+	case !p.CanAccessTeam(team):
+		return "team is outside of token scope"
+	}
+	return ""
+}
+
+// allowUserTeam — allowTeam для команды пользователя userID.
+// Команду читаем только для токенов, привязанных к команде
+func (h *Handler) allowUserTeam(w http.ResponseWriter, r *http.Request, db *gorm.DB, userID string) bool {
+	if p := auth.FromContext(r.Context()); p == nil || p.Team == "" {
+		return h.allowTeam(w, r, "")
+	}
+	var u model.UserDB
+	if err := db.Select("team_name").First(&u, "user_id = ?", userID).Error; err != nil {
+		h.dbErr(w, r, err)
+		return false
+	}
+	return h.allowTeam(w, r, u.TeamName)
+}
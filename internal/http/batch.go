@@ -0,0 +1,203 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+	"github.com/alinaaved/pr-reviewer/internal/selector"
+)
+
+// maxBatchPRs — сколько PR можно создать одним POST /pullRequest/createBatch
+const maxBatchPRs = 100
+
+// PRCreateBatch обрабатывает POST /pullRequest/createBatch
+// POST /pullRequest/createBatch {pull_requests:[{pull_request_id, pull_request_name, author_id}, ...]}
+// 200 {created, failed, results:[...]} | 400
+//
+// Все PR пишутся одной транзакцией. Ошибка элемента (BAD_REQUEST, PR_EXISTS, NOT_FOUND, FORBIDDEN)
+// попадает в его результат и не мешает остальным; ошибка БД откатывает всю пачку.
+// Ревьюверы распределяются с учётом назначений, уже сделанных в этой пачке (selector.Batch),
+// правил назначения (reviewer_rules) и требования команды к числу senior
+func (h *Handler) PRCreateBatch(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	var in BatchCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeErr(w, "BAD_REQUEST", "invalid json", http.StatusBadRequest)
+		return
+	}
+	if len(in.PullRequests) == 0 || len(in.PullRequests) > maxBatchPRs {
+		writeErr(w, "BAD_REQUEST", fmt.Sprintf("pull_requests must contain 1..%d items", maxBatchPRs), http.StatusBadRequest)
+		return
+	}
+
+	ids := make([]string, 0, len(in.PullRequests))
+	authorIDs := make([]string, 0, len(in.PullRequests))
+	for _, it := range in.PullRequests {
+		ids = append(ids, it.PullRequestID)
+		authorIDs = append(authorIDs, it.AuthorID)
+	}
+
+	var out BatchCreateResult
+	var free []int // незаполненные слоты созданных PR — для метрик после коммита
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		out = BatchCreateResult{Results: make([]BatchItemResult, len(in.PullRequests))}
+		free = free[:0]
+
+		var users []model.UserDB
+		if err := tx.Where("user_id IN ?", authorIDs).Find(&users).Error; err != nil {
+			return err
+		}
+		authors := make(map[string]model.UserDB, len(users))
+		for _, u := range users {
+			authors[u.UserID] = u
+		}
+		var existing []string
+		if err := tx.Model(&model.PullRequestDB{}).Where("pull_request_id IN ?", ids).
+			Pluck("pull_request_id", &existing).Error; err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(ids)+len(existing))
+		for _, id := range existing {
+			seen[id] = true
+		}
+		rules, err := loadPairRules(tx, authorIDs...)
+		if err != nil {
+			return err
+		}
+		teamNames := make([]string, 0, len(users))
+		for _, u := range users {
+			teamNames = append(teamNames, u.TeamName)
+		}
+		var teamRows []model.TeamDB
+		if err := tx.Where("team_name IN ?", teamNames).Find(&teamRows).Error; err != nil {
+			return err
+		}
+		comps := make(map[string]selector.Composition, len(teamRows))
+		for _, t := range teamRows {
+			comps[t.TeamName] = selector.Composition{Level: model.LevelSenior, Min: int(t.MinSeniorReviewers)}
+		}
+
+		// кандидаты читаются один раз на команду; Batch помнит выбор внутри пачки
+		teams := map[string][]selector.Candidate{}
+		sel := selector.NewBatch(h.strategy(r))
+		now := time.Now()
+		var prs []model.PullRequestDB
+		var slots []model.PRReviewerDB
+		var events []model.PREventDB
+		event := func(prID, kind, newUserID string) {
+			ev := model.PREventDB{PRID: prID, Kind: kind, CreatedAt: now}
+			if newUserID != "" {
+				ev.NewUserID = &newUserID
+			}
+			events = append(events, ev)
+		}
+
+		for i, it := range in.PullRequests {
+			res := &out.Results[i]
+			*res = BatchItemResult{Index: i, PullRequestID: it.PullRequestID}
+			author, ok := authors[it.AuthorID]
+			switch {
+			case it.PullRequestID == "" || it.PullRequestName == "" || it.AuthorID == "":
+				res.Code, res.Message = "BAD_REQUEST", "pull_request_id, pull_request_name and author_id are required"
+			case seen[it.PullRequestID]:
+				res.Code, res.Message = "PR_EXISTS", "PR id already exists"
+			case !ok:
+				res.Code, res.Message = "NOT_FOUND", "author not found"
+			default:
+				res.Message = teamDenied(r, author.TeamName)
+				if res.Message != "" {
+					res.Code = "FORBIDDEN"
+				}
+			}
+			if res.Code != "" {
+				out.Failed++
+				continue
+			}
+			seen[it.PullRequestID] = true
+
+			cands, ok := teams[author.TeamName]
+			if !ok {
+				var err error
+				if cands, err = loadCandidates(tx, author.TeamName, "", nil); err != nil {
+					return err
+				}
+				teams[author.TeamName] = cands
+			}
+			// правила автора: обязательный ревьювер — первым, исключённые не назначаются;
+			// требование команды к числу senior учитывает и обязательного
+			p, comp := rules[it.AuthorID], comps[author.TeamName]
+			var picked []selector.Candidate
+			if len(p.require) > 0 {
+				req, err := loadCandidatesIn(tx, p.required(), []string{it.AuthorID})
+				if err != nil {
+					return err
+				}
+				picked = selector.PickComposed(sel, req, 1, comp, 0)
+			}
+			others := make([]selector.Candidate, 0, len(cands))
+			for _, c := range cands {
+				if c.UserID != it.AuthorID && !p.exclude[c.UserID] && (len(picked) == 0 || c.UserID != picked[0].UserID) {
+					others = append(others, c)
+				}
+			}
+			picked = append(picked, selector.PickComposed(sel, others, h.reviewers-len(picked), comp,
+				selector.CountLevel(picked, comp.Level))...)
+
+			pr := PullRequest{
+				PullRequestID:   it.PullRequestID,
+				PullRequestName: it.PullRequestName,
+				AuthorID:        it.AuthorID,
+				Status:          "OPEN",
+				Assigned:        make([]string, 0, len(picked)),
+				CreatedAt:       &now,
+				Version:         1,
+			}
+			prs = append(prs, model.PullRequestDB{
+				ID:        it.PullRequestID,
+				Name:      it.PullRequestName,
+				AuthorID:  it.AuthorID,
+				Status:    "OPEN",
+				CreatedAt: now,
+				Version:   1,
+			})
+			event(it.PullRequestID, model.EventCreated, "")
+			for j, c := range picked {
+				pr.Assigned = append(pr.Assigned, c.UserID)
+				slots = append(slots, model.PRReviewerDB{PRID: it.PullRequestID, ReviewerID: c.UserID, Position: int16(j + 1)})
+				event(it.PullRequestID, model.EventAssigned, c.UserID)
+			}
+			for j := len(picked); j < h.reviewers; j++ {
+				event(it.PullRequestID, model.EventNoCandidate, "")
+			}
+			res.PR = &pr
+			out.Created++
+			free = append(free, h.reviewers-len(picked))
+		}
+
+		// по одному INSERT на таблицу вместо нескольких запросов на каждый PR
+		if len(prs) == 0 {
+			return nil
+		}
+		if err := tx.Create(&prs).Error; err != nil {
+			return err
+		}
+		if len(slots) > 0 {
+			if err := tx.Create(&slots).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&events).Error
+	}); err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	for _, n := range free {
+		h.metrics.PRCreated(n)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
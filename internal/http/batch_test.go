@@ -0,0 +1,25 @@
+package httpapi_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPRCreateBatch_BadSize(t *testing.T) {
+	h := dryRunHandler(t)
+	for _, n := range []int{0, 101} {
+		items := make([]string, n)
+		for i := range items {
+			items[i] = fmt.Sprintf(`{"pull_request_id":"pr-%d","pull_request_name":"x","author_id":"u1"}`, i)
+		}
+		body := `{"pull_requests":[` + strings.Join(items, ",") + `]}`
+		rec := httptest.NewRecorder()
+		h.PRCreateBatch(rec, httptest.NewRequest(http.MethodPost, "/pullRequest/createBatch", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%d items: status=%d (want 400)", n, rec.Code)
+		}
+	}
+}
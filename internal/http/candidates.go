@@ -0,0 +1,170 @@
+package httpapi
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+	"github.com/alinaaved/pr-reviewer/internal/selector"
+)
+
+// ruleApplies — правило x действует для автора a: глобальное или команды, в которой автор сейчас
+const ruleApplies = "(x.team_name IS NULL OR x.team_name = a.team_name)"
+
+// candidateQuery — активные пользователи с уровнем, числом открытых PR на ревью и лимитом
+// открытых ревью у каждого (свой, иначе команды; 0 — без ограничения)
+func candidateQuery(tx *gorm.DB) *gorm.DB {
+	return tx.Table("users AS u").
+		Select("u.user_id, u.level, COUNT(pr.pull_request_id) AS open_reviews, " +
+			"COALESCE(u.max_open_reviews, t.max_open_reviews, 0) AS capacity").
+		Joins("JOIN teams t ON t.team_name = u.team_name").
+		Joins("LEFT JOIN pr_reviewers r ON r.reviewer_id = u.user_id").
+		Joins("LEFT JOIN pull_requests pr ON pr.pull_request_id = r.pr_id AND pr.status = 'OPEN'").
+		Where("u.is_active = TRUE")
+}
+
+// loadCandidates возвращает активных участников команды team, кроме автора authorID, exclude
+// и исключённых для этого автора правилами EXCLUDE, с числом открытых PR на ревью у каждого
+// (порядок стабильный — по user_id). Пустой authorID — вся команда без учёта правил
+func loadCandidates(tx *gorm.DB, team, authorID string, exclude []string) ([]selector.Candidate, error) {
+	var out []selector.Candidate
+	q := candidateQuery(tx).
+		Where("u.team_name = ? AND u.user_id <> ?", team, authorID).
+		Where(`NOT EXISTS (SELECT 1 FROM reviewer_rules x JOIN users a ON a.user_id = x.author_id
+			WHERE x.kind = ? AND x.author_id = ? AND x.reviewer_id = u.user_id AND `+ruleApplies+`)`,
+			model.RuleExclude, authorID)
+	if len(exclude) > 0 {
+		q = q.Where("u.user_id NOT IN ?", exclude)
+	}
+	err := q.Group("u.user_id, t.team_name").Order("u.user_id").Scan(&out).Error
+	return out, err
+}
+
+// loadCandidatesIn — активные пользователи ids из любой команды, кроме exclude
+func loadCandidatesIn(tx *gorm.DB, ids, exclude []string) ([]selector.Candidate, error) {
+	var out []selector.Candidate
+	if len(ids) == 0 {
+		return out, nil
+	}
+	q := candidateQuery(tx).Where("u.user_id IN ?", ids)
+	if len(exclude) > 0 {
+		q = q.Where("u.user_id NOT IN ?", exclude)
+	}
+	err := q.Group("u.user_id, t.team_name").Order("u.user_id").Scan(&out).Error
+	return out, err
+}
+
+// pairRules — действующие правила одного автора: кого не назначать и из кого назначать обязательно
+type pairRules struct {
+	exclude, require map[string]bool
+}
+
+// required — user_id из правил REQUIRE
+func (p pairRules) required() []string {
+	out := make([]string, 0, len(p.require))
+	for id := range p.require {
+		out = append(out, id)
+	}
+	return out
+}
+
+// hasRequired сообщает, есть ли среди ids кто-то из правил REQUIRE
+func (p pairRules) hasRequired(ids []string) bool {
+	for _, id := range ids {
+		if p.require[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// loadPairRules читает правила, действующие для авторов authorIDs
+func loadPairRules(tx *gorm.DB, authorIDs ...string) (map[string]pairRules, error) {
+	var rows []model.ReviewerRuleDB
+	if err := tx.Table("reviewer_rules AS x").
+		Select("x.kind, x.author_id, x.reviewer_id").
+		Joins("JOIN users a ON a.user_id = x.author_id").
+		Where("x.author_id IN ? AND "+ruleApplies, authorIDs).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]pairRules, len(authorIDs))
+	for _, x := range rows {
+		p, ok := out[x.AuthorID]
+		if !ok {
+			p = pairRules{exclude: map[string]bool{}, require: map[string]bool{}}
+			out[x.AuthorID] = p
+		}
+		if x.Kind == model.RuleExclude {
+			p.exclude[x.ReviewerID] = true
+		} else {
+			p.require[x.ReviewerID] = true
+		}
+	}
+	return out, nil
+}
+
+// composition — требование команды автора authorID к составу ревьюверов
+func composition(tx *gorm.DB, authorID string) (selector.Composition, error) {
+	var team model.TeamDB
+	err := tx.Table("teams AS t").
+		Select("t.min_senior_reviewers").
+		Joins("JOIN users a ON a.team_name = t.team_name").
+		Where("a.user_id = ?", authorID).
+		Scan(&team).Error
+	return selector.Composition{Level: model.LevelSenior, Min: int(team.MinSeniorReviewers)}, err
+}
+
+// countLevel — сколько из пользователей ids уровня level
+func countLevel(tx *gorm.DB, ids []string, level string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	var n int64
+	err := tx.Model(&model.UserDB{}).Where("user_id IN ? AND level = ?", ids, level).Count(&n).Error
+	return int(n), err
+}
+
+// pickReviewers дополняет chosen до h.reviewers. Если у автора есть правила REQUIRE, а в chosen
+// никого из них нет, первым добавляется один из обязательных (из любой команды); остальные —
+// из команды автора так, чтобы выполнялось требование команды к числу senior.
+// Кандидатов выбирает sel; exclude — кого не назначать сверх chosen (например, отказавшихся)
+func (h *Handler) pickReviewers(tx *gorm.DB, sel selector.Strategy, author model.UserDB, chosen, exclude []string) ([]string, error) {
+	out := append([]string(nil), chosen...)
+	if len(out) >= h.reviewers {
+		return out, nil
+	}
+	rules, err := loadPairRules(tx, author.UserID)
+	if err != nil {
+		return nil, err
+	}
+	comp, err := composition(tx, author.UserID)
+	if err != nil {
+		return nil, err
+	}
+	have, err := countLevel(tx, out, comp.Level)
+	if err != nil {
+		return nil, err
+	}
+	skip := append(append([]string{author.UserID}, exclude...), chosen...)
+	if p := rules[author.UserID]; len(p.require) > 0 && !p.hasRequired(chosen) {
+		cands, err := loadCandidatesIn(tx, p.required(), skip)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range selector.PickComposed(sel, cands, 1, comp, have) {
+			out = append(out, c.UserID)
+			skip = append(skip, c.UserID)
+			if c.Level == comp.Level {
+				have++
+			}
+		}
+	}
+	cands, err := loadCandidates(tx, author.TeamName, author.UserID, skip)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range selector.PickComposed(sel, cands, h.reviewers-len(out), comp, have) {
+		out = append(out, c.UserID)
+	}
+	return out, nil
+}
@@ -0,0 +1,105 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+// sameLimit сравнивает лимиты открытых ревью; nil — без своего лимита
+func sameLimit(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// UsersSetCapacity обрабатывает POST /users/setCapacity
+// POST /users/setCapacity { user_id, max_open_reviews|null } -> 200 {user:{...}} | 400 | 403 | 404
+//
+// Пользователь с max_open_reviews открытых ревью не назначается автоматически, пока одно из них
+// не закроется; null снимает собственный лимит — действует лимит команды.
+// Текущие назначения не меняются, ручной выбор (requested_reviewers, setReviewers) лимит не проверяет
+func (h *Handler) UsersSetCapacity(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	var in struct {
+		UserID         string `json:"user_id"`
+		MaxOpenReviews *int   `json:"max_open_reviews"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeErr(w, "BAD_REQUEST", "invalid json", http.StatusBadRequest)
+		return
+	}
+	if in.MaxOpenReviews != nil && *in.MaxOpenReviews <= 0 {
+		writeErr(w, "BAD_REQUEST", "max_open_reviews must be positive or null", http.StatusBadRequest)
+		return
+	}
+
+	var u model.UserDB
+	if err := db.First(&u, "user_id = ?", in.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeErr(w, "NOT_FOUND", "user not found", http.StatusNotFound)
+			return
+		}
+		h.dbErr(w, r, err)
+		return
+	}
+	if !h.allowTeam(w, r, u.TeamName) {
+		return
+	}
+	if err := db.Model(&u).Update("max_open_reviews", in.MaxOpenReviews).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"user": User{
+			UserID:         u.UserID,
+			Username:       u.Username,
+			TeamName:       u.TeamName,
+			IsActive:       u.IsActive,
+			Level:          u.Level,
+			MaxOpenReviews: in.MaxOpenReviews,
+		},
+	})
+}
+
+// TeamSetCapacity обрабатывает POST /team/setCapacity
+// POST /team/setCapacity { team_name, max_open_reviews|null } -> 200 {team_name, max_open_reviews} | 400 | 403 | 404
+//
+// Лимит команды действует для участников без собственного; null — без ограничения
+func (h *Handler) TeamSetCapacity(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	var in struct {
+		TeamName       string `json:"team_name"`
+		MaxOpenReviews *int   `json:"max_open_reviews"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.TeamName == "" {
+		writeErr(w, "BAD_REQUEST", "team_name is required", http.StatusBadRequest)
+		return
+	}
+	if in.MaxOpenReviews != nil && *in.MaxOpenReviews <= 0 {
+		writeErr(w, "BAD_REQUEST", "max_open_reviews must be positive or null", http.StatusBadRequest)
+		return
+	}
+	if !h.allowTeam(w, r, in.TeamName) {
+		return
+	}
+	res := db.Model(&model.TeamDB{}).Where("team_name = ?", in.TeamName).
+		Update("max_open_reviews", in.MaxOpenReviews)
+	if res.Error != nil {
+		h.dbErr(w, r, res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		writeErr(w, "NOT_FOUND", "team not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"team_name":        in.TeamName,
+		"max_open_reviews": in.MaxOpenReviews,
+	})
+}
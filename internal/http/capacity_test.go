@@ -0,0 +1,30 @@
+package httpapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCapacity_BadRequest(t *testing.T) {
+	h := dryRunHandler(t)
+	cases := []struct {
+		path string
+		fn   http.HandlerFunc
+		body string
+	}{
+		{"/team/add", h.TeamAdd, `{"team_name":"backend","max_open_reviews":0}`},
+		{"/team/add", h.TeamAdd, `{"team_name":"backend","members":[{"user_id":"u1","username":"A","max_open_reviews":-2}]}`},
+		{"/users/setCapacity", h.UsersSetCapacity, `{"user_id":"u1","max_open_reviews":0}`},
+		{"/team/setCapacity", h.TeamSetCapacity, `{"max_open_reviews":3}`},
+		{"/team/setCapacity", h.TeamSetCapacity, `{"team_name":"backend","max_open_reviews":-1}`},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		tc.fn(rec, httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s %s: status=%d (want 400)", tc.path, tc.body, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/auth"
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+// declineReasons — допустимые причины отказа ревьювера
+var declineReasons = map[string]bool{
+	model.DeclineBusy:               true,
+	model.DeclineLacksContext:       true,
+	model.DeclineConflictOfInterest: true,
+}
+
+// PRDecline обрабатывает POST /pullRequest/decline
+// POST /pullRequest/decline { pull_request_id, user_id?, reason } -> 200 { pr:{...}, replaced_by, reason } + ETag
+// 400 | 403 | 404 NOT_FOUND | 409 PR_MERGED | NOT_ASSIGNED | 412 PRECONDITION_FAILED (If-Match)
+//
+// Назначенный ревьювер сам отказывается от ревью с причиной при любой роли; user_id по умолчанию —
+// вызывающий. Статический токен не привязан к пользователю: с ним отказ только за другого (writer/admin).
+// Замена выбирается, как при reassign; если её нет, слот снимается (replaced_by пуст) —
+// отказ всё равно принимается. Причина пишется в историю и видна в /stats/*
+func (h *Handler) PRDecline(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	var in struct {
+		PRID   string `json:"pull_request_id"`
+		UserID string `json:"user_id"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeErr(w, "BAD_REQUEST", "invalid json", http.StatusBadRequest)
+		return
+	}
+	var caller string
+	if p := auth.FromContext(r.Context()); p != nil {
+		caller = p.UserID()
+	}
+	if in.UserID == "" {
+		in.UserID = caller
+	}
+	if in.PRID == "" || in.UserID == "" {
+		writeErr(w, "BAD_REQUEST", "pull_request_id and user_id are required", http.StatusBadRequest)
+		return
+	}
+	if !declineReasons[in.Reason] {
+		writeErr(w, "BAD_REQUEST", "reason must be BUSY, LACKS_CONTEXT or CONFLICT_OF_INTEREST", http.StatusBadRequest)
+		return
+	}
+	// ревьювер отказывается сам за себя при любой роли; за другого — только с правом изменять команду автора
+	self := caller != "" && caller == in.UserID
+
+	var out DeclineResult
+	err := db.Transaction(func(tx *gorm.DB) error {
+		pr, err := lockPR(tx, in.PRID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				writeErr(w, "NOT_FOUND", "PR not found", http.StatusNotFound)
+				return errStop
+			}
+			return err
+		}
+		if !self && !h.allowUserTeam(w, r, tx, pr.AuthorID) {
+			return errStop
+		}
+		if pr.Status == "MERGED" {
+			writeErr(w, "PR_MERGED", "cannot decline review on merged PR", http.StatusConflict)
+			return errStop
+		}
+		if !ifMatch(r, pr.Version) {
+			writePreconditionFailed(w, pr.Version)
+			return errStop
+		}
+
+		var slot model.PRReviewerDB
+		if err := tx.First(&slot, "pr_id = ? AND reviewer_id = ?", pr.ID, in.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				writeErr(w, "NOT_ASSIGNED", "reviewer is not assigned to this PR", http.StatusConflict)
+				return errStop
+			}
+			return err
+		}
+		var reviewer model.UserDB
+		if err := tx.First(&reviewer, "user_id = ?", in.UserID).Error; err != nil {
+			return err
+		}
+
+		newID, err := h.replaceReviewer(tx, h.strategy(r), pr, slot, reviewer.TeamName, in.Reason)
+		switch {
+		case errors.Is(err, errNoCandidate):
+			// замены нет — ревьювер всё равно снимается, слот остаётся пустым
+			if err := tx.Where("pr_id = ? AND position = ?", pr.ID, slot.Position).
+				Delete(&model.PRReviewerDB{}).Error; err != nil {
+				return err
+			}
+			if err := recordUnfilled(tx, pr.ID, in.UserID, in.Reason); err != nil {
+				return err
+			}
+			if err := bumpVersion(tx, pr.ID); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		}
+		pr.Version++
+		out.PR, err = h.buildPR(tx, pr)
+		out.ReplacedBy, out.Reason = newID, in.Reason
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, errStop) {
+			h.dbErr(w, r, err)
+		}
+		return
+	}
+	// метрики — только после коммита
+	h.metrics.Declined(in.Reason)
+	if out.ReplacedBy == "" {
+		h.metrics.NoCandidate("decline")
+	} else {
+		h.metrics.Reassigned()
+	}
+	w.Header().Set("ETag", prETag(out.PR.Version))
+	writeJSON(w, http.StatusOK, out)
+}
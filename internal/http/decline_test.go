@@ -0,0 +1,24 @@
+package httpapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPRDecline_BadRequest(t *testing.T) {
+	h := dryRunHandler(t)
+	for _, body := range []string{
+		`{`,
+		`{"pull_request_id":"pr-1","reason":"BUSY"}`, // без user_id и без аутентификации
+		`{"pull_request_id":"pr-1","user_id":"u2"}`,
+		`{"pull_request_id":"pr-1","user_id":"u2","reason":"bored"}`,
+	} {
+		rec := httptest.NewRecorder()
+		h.PRDecline(rec, httptest.NewRequest(http.MethodPost, "/pullRequest/decline", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status=%d (want 400)", body, rec.Code)
+		}
+	}
+}
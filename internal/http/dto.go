@@ -15,13 +15,21 @@ type ErrorResponse struct {
 type TeamMember struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
+	IsActive *bool  `json:"is_active,omitempty"` // не указан — активен
+	Level    string `json:"level,omitempty"`     // junior | mid | senior; не указан — mid для новых, прежний для существующих
+	// MaxOpenReviews — лимит открытых ревью; не указан — лимит команды для новых, прежний для существующих
+	MaxOpenReviews *int `json:"max_open_reviews,omitempty"`
 }
 
+// Active — активность участника с учётом умолчания
+func (m TeamMember) Active() bool { return m.IsActive == nil || *m.IsActive }
+
 // Team — команда с участниками (DTO)
 type Team struct {
-	TeamName string       `json:"team_name"`
-	Members  []TeamMember `json:"members"`
+	TeamName           string       `json:"team_name"`
+	Members            []TeamMember `json:"members"`
+	MinSeniorReviewers int          `json:"min_senior_reviewers,omitempty"` // сколько senior нужно среди ревьюверов PR
+	MaxOpenReviews     *int         `json:"max_open_reviews,omitempty"`     // лимит открытых ревью участника по умолчанию
 }
 
 // User — пользователь (DTO).
@@ -30,6 +38,9 @@ type User struct {
 	Username string `json:"username"`
 	TeamName string `json:"team_name"`
 	IsActive bool   `json:"is_active"`
+	Level    string `json:"level"`
+	// MaxOpenReviews — собственный лимит открытых ревью; нет — действует лимит команды
+	MaxOpenReviews *int `json:"max_open_reviews,omitempty"`
 }
 
 // PullRequestShort — краткая информация о PR (для списков)
@@ -40,6 +51,14 @@ type PullRequestShort struct {
 	Status          string `json:"status"`
 }
 
+// ReviewItem — PR в списке ревью пользователя (GET /users/getReview).
+// Возраст заполняется только по include=age
+type ReviewItem struct {
+	PullRequestShort
+	AssignedAt *time.Time `json:"assigned_at,omitempty"`
+	AgeSeconds *int64     `json:"age_seconds,omitempty"`
+}
+
 // PullRequest — полный ответ по PR
 type PullRequest struct {
 	PullRequestID   string     `json:"pull_request_id"`
@@ -47,6 +66,231 @@ type PullRequest struct {
 	AuthorID        string     `json:"author_id"`
 	Status          string     `json:"status"`
 	Assigned        []string   `json:"assigned_reviewers"`
+	Pinned          []string   `json:"pinned_reviewers,omitempty"` // закреплены вручную, не переназначаются автоматически
 	CreatedAt       *time.Time `json:"createdAt,omitempty"`
 	MergedAt        *time.Time `json:"mergedAt,omitempty"`
+	Version         int64      `json:"version"` // версия для If-Match (ETag "v<version>")
+}
+
+// PREvent — событие истории PR (создание, переназначение, merge)
+type PREvent struct {
+	Kind      string    `json:"kind"`
+	OldUserID string    `json:"old_user_id,omitempty"`
+	NewUserID string    `json:"new_user_id,omitempty"`
+	Reason    string    `json:"reason,omitempty"` // причина отказа ревьювера
+	At        time.Time `json:"at"`
+}
+
+// PRHistory — сводка истории PR
+type PRHistory struct {
+	Reassignments int       `json:"reassignments"`
+	Events        []PREvent `json:"events"`
+}
+
+// PullRequestDetail — PR с данными ревьюверов и историей (GET /pullRequest/get)
+type PullRequestDetail struct {
+	PullRequest
+	Reviewers []User    `json:"reviewers"`
+	History   PRHistory `json:"history"`
+}
+
+// AssignmentCount — число назначений пользователя ревьювером
+type AssignmentCount struct {
+	UserID string `json:"user_id"`
+	Count  int64  `json:"count"`
+}
+
+// ReviewerStats — статистика ревьювера за окно (GET /stats/reviewers)
+type ReviewerStats struct {
+	UserID        string `json:"user_id"`
+	TeamName      string `json:"team_name"`
+	IsActive      bool   `json:"is_active"`
+	Assigned      int64  `json:"assigned"`
+	ReassignedIn  int64  `json:"reassigned_in"`
+	ReassignedOut int64  `json:"reassigned_out"`
+	Declined      int64  `json:"declined"` // отказы ревьювера (входят в reassigned_out)
+	OpenLoad      int64  `json:"open_load"`
+}
+
+// AuthorStats — статистика автора за окно (GET /stats/authors)
+type AuthorStats struct {
+	UserID                   string   `json:"user_id"`
+	TeamName                 string   `json:"team_name"`
+	PRsCreated               int64    `json:"prs_created"`
+	PRsMerged                int64    `json:"prs_merged"`
+	PRsOpen                  int64    `json:"prs_open"`
+	MedianTimeToMergeSeconds *float64 `json:"median_time_to_merge_seconds"`
+}
+
+// StatsSummary — сводка по PR и назначениям за окно (GET /stats/summary)
+type StatsSummary struct {
+	From                           time.Time        `json:"from"`
+	To                             time.Time        `json:"to"`
+	TeamName                       string           `json:"team_name"`
+	PRsCreated                     int64            `json:"prs_created"`
+	PRsMerged                      int64            `json:"prs_merged"`
+	PRsOpen                        int64            `json:"prs_open"`
+	MedianTimeToMergeSeconds       *float64         `json:"median_time_to_merge_seconds"`
+	MedianTimeToFirstReviewSeconds *float64         `json:"median_time_to_first_review_seconds"`
+	Assignments                    int64            `json:"assignments"`
+	Reassignments                  int64            `json:"reassignments"`
+	NoCandidate                    int64            `json:"no_candidate"`
+	NoCandidateRate                float64          `json:"no_candidate_rate"`
+	DeclinesByReason               map[string]int64 `json:"declines_by_reason"`
+}
+
+// FairnessMember — участник в отчёте о справедливости
+type FairnessMember struct {
+	UserID          string  `json:"user_id"`
+	Username        string  `json:"username"`
+	IsActive        bool    `json:"is_active"`
+	ActiveHours     float64 `json:"active_hours"`
+	ActiveShare     float64 `json:"active_share"`
+	Assignments     int64   `json:"assignments"`
+	AssignmentShare float64 `json:"assignment_share"`
+	LoadRatio       float64 `json:"load_ratio"`
+	Outlier         string  `json:"outlier,omitempty"`
+}
+
+// FairnessReport — отчёт о распределении ревью в команде (GET /stats/fairness)
+type FairnessReport struct {
+	TeamName         string           `json:"team_name"`
+	From             time.Time        `json:"from"`
+	To               time.Time        `json:"to"`
+	OutlierRatio     float64          `json:"outlier_ratio"`
+	TotalAssignments int64            `json:"total_assignments"`
+	Gini             float64          `json:"gini"`
+	Members          []FairnessMember `json:"members"`
+}
+
+// ExportPR — PR с ревьюверами (dataset pullRequests)
+type ExportPR struct {
+	PullRequestID   string     `json:"pull_request_id"`
+	PullRequestName string     `json:"pull_request_name"`
+	AuthorID        string     `json:"author_id"`
+	AuthorTeam      string     `json:"author_team"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"created_at"`
+	MergedAt        *time.Time `json:"merged_at"`
+	Reviewers       []string   `json:"reviewers"`
+}
+
+// ExportAssignment — текущий слот ревьювера (dataset assignments)
+type ExportAssignment struct {
+	PullRequestID string     `json:"pull_request_id"`
+	Position      int16      `json:"position"`
+	ReviewerID    string     `json:"reviewer_id"`
+	ReviewerTeam  string     `json:"reviewer_team"`
+	AuthorID      string     `json:"author_id"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	MergedAt      *time.Time `json:"merged_at"`
+}
+
+// ExportEvent — событие истории PR (dataset events)
+type ExportEvent struct {
+	ID            int64     `json:"id"`
+	PullRequestID string    `json:"pull_request_id"`
+	Kind          string    `json:"kind"`
+	OldUserID     *string   `json:"old_user_id"`
+	NewUserID     *string   `json:"new_user_id"`
+	Reason        *string   `json:"reason"`
+	At            time.Time `json:"at"`
+}
+
+// ReviewerRule — правило назначения для пары автор/ревьювер (EXCLUDE | REQUIRE);
+// TeamName пуст у глобального правила
+type ReviewerRule struct {
+	ID         int64     `json:"id"`
+	Kind       string    `json:"kind"`
+	TeamName   string    `json:"team_name,omitempty"`
+	AuthorID   string    `json:"author_id"`
+	ReviewerID string    `json:"reviewer_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DeclineResult — ответ POST /pullRequest/decline; ReplacedBy пуст, если замены не нашлось и слот снят
+type DeclineResult struct {
+	PR         PullRequest `json:"pr"`
+	ReplacedBy string      `json:"replaced_by"`
+	Reason     string      `json:"reason"`
+}
+
+// ImportRowError — ошибка строки импорта (номер строки NDJSON, с 1)
+type ImportRowError struct {
+	Line          int    `json:"line"`
+	PullRequestID string `json:"pull_request_id,omitempty"`
+	Code          string `json:"code"`
+	Message       string `json:"message"`
+}
+
+// ImportResult — итог POST /pullRequest/import
+type ImportResult struct {
+	Imported int              `json:"imported"`
+	Failed   int              `json:"failed"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// NewPR — PR для создания (элемент POST /pullRequest/createBatch)
+type NewPR struct {
+	PullRequestID   string `json:"pull_request_id"`
+	PullRequestName string `json:"pull_request_name"`
+	AuthorID        string `json:"author_id"`
+}
+
+// BatchCreateRequest — тело POST /pullRequest/createBatch
+type BatchCreateRequest struct {
+	PullRequests []NewPR `json:"pull_requests"`
+}
+
+// BatchItemResult — результат одного элемента POST /pullRequest/createBatch:
+// при успехе заполнен PR, при ошибке — Code и Message
+type BatchItemResult struct {
+	Index         int          `json:"index"`
+	PullRequestID string       `json:"pull_request_id"`
+	PR            *PullRequest `json:"pr,omitempty"`
+	Code          string       `json:"code,omitempty"`
+	Message       string       `json:"message,omitempty"`
+}
+
+// BatchCreateResult — итог POST /pullRequest/createBatch (results в порядке запроса)
+type BatchCreateResult struct {
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Results []BatchItemResult `json:"results"`
+}
+
+// RosterSyncRequest — желаемый состав всех команд (POST /team/sync)
+type RosterSyncRequest struct {
+	Teams  []Team `json:"teams"`
+	DryRun bool   `json:"dry_run"`
+}
+
+// UserMove — перевод пользователя между командами
+type UserMove struct {
+	UserID string `json:"user_id"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// RosterReassignment — открытое ревью деактивированного пользователя.
+// NewUserID пуст в dry-run и когда замены нет (тогда Unassigned=true)
+type RosterReassignment struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldUserID     string `json:"old_user_id"`
+	NewUserID     string `json:"new_user_id,omitempty"`
+	Unassigned    bool   `json:"unassigned,omitempty"`
+}
+
+// RosterDiff — изменения, которые внесла (или внесёт в dry-run) синхронизация
+type RosterDiff struct {
+	TeamsCreated     []string             `json:"teams_created"`
+	UsersCreated     []string             `json:"users_created"`
+	UsersMoved       []UserMove           `json:"users_moved"`
+	UsersRenamed     []string             `json:"users_renamed"`
+	UsersActivated   []string             `json:"users_activated"`
+	UsersDeactivated []string             `json:"users_deactivated"`
+	UsersLeveled     []string             `json:"users_leveled"` // сменился уровень
+	TeamsUpdated     []string             `json:"teams_updated"` // сменились min_senior_reviewers или max_open_reviews
+	Reassignments    []RosterReassignment `json:"reassignments"`
 }
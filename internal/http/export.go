@@ -0,0 +1,267 @@
+package httpapi
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// exportFlushEvery — через сколько строк сбрасывать буфер клиенту
+const exportFlushEvery = 500
+
+// exportQuery — параметры выгрузки: окно [From, To) (любая граница может отсутствовать) и формат
+type exportQuery struct {
+	From   *time.Time
+	To     *time.Time
+	Format string // csv | ndjson
+}
+
+func parseExportQuery(q url.Values) (exportQuery, error) {
+	out := exportQuery{Format: q.Get("format")}
+	switch out.Format {
+	case "":
+		out.Format = "ndjson"
+	case "csv", "ndjson":
+	default:
+		return out, errors.New("format must be csv or ndjson")
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &out.From}, {"to", &out.To}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return out, fmt.Errorf("%s must be RFC3339", p.name)
+		}
+		*p.dst = &t
+	}
+	if out.From != nil && out.To != nil && !out.From.Before(*out.To) {
+		return out, errors.New("from must be before to")
+	}
+	return out, nil
+}
+
+// window добавляет фильтр окна по колонке col
+func (q exportQuery) window(db *gorm.DB, col string) *gorm.DB {
+	if q.From != nil {
+		db = db.Where(col+" >= ?", *q.From)
+	}
+	if q.To != nil {
+		db = db.Where(col+" < ?", *q.To)
+	}
+	return db
+}
+
+// exportRecord — строка выгрузки: колонки CSV и объект для NDJSON
+type exportRecord interface {
+	csvRow() []string
+}
+
+// exportDataset описывает набор данных выгрузки
+type exportDataset struct {
+	header []string
+	query  func(db *gorm.DB, q exportQuery) *gorm.DB
+	scan   func(db *gorm.DB, rows *sql.Rows) (exportRecord, error)
+}
+
+func (p ExportPR) csvRow() []string {
+	return []string{p.PullRequestID, p.PullRequestName, p.AuthorID, p.AuthorTeam, p.Status,
+		p.CreatedAt.Format(time.RFC3339Nano), formatTimePtr(p.MergedAt), strings.Join(p.Reviewers, ";")}
+}
+
+func (a ExportAssignment) csvRow() []string {
+	return []string{a.PullRequestID, strconv.Itoa(int(a.Position)), a.ReviewerID, a.ReviewerTeam, a.AuthorID,
+		a.Status, a.CreatedAt.Format(time.RFC3339Nano), formatTimePtr(a.MergedAt)}
+}
+
+func (e ExportEvent) csvRow() []string {
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return []string{strconv.FormatInt(e.ID, 10), e.PullRequestID, e.Kind, deref(e.OldUserID), deref(e.NewUserID),
+		deref(e.Reason), e.At.Format(time.RFC3339Nano)}
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// exportDatasets — доступные выгрузки; окно для PR и слотов — по created_at PR, для событий — по времени события
+var exportDatasets = map[string]exportDataset{
+	"pullRequests": {
+		header: []string{"pull_request_id", "pull_request_name", "author_id", "author_team", "status",
+			"created_at", "merged_at", "reviewers"},
+		query: func(db *gorm.DB, q exportQuery) *gorm.DB {
+			return q.window(db.Table("pull_requests AS pr").
+				Select(`pr.pull_request_id, pr.pull_request_name, pr.author_id, a.team_name AS author_team,
+					pr.status, pr.created_at, pr.merged_at,
+					COALESCE((SELECT string_agg(r.reviewer_id, ',' ORDER BY r.position)
+						FROM pr_reviewers r WHERE r.pr_id = pr.pull_request_id), '') AS reviewers`).
+				Joins("JOIN users a ON a.user_id = pr.author_id"), "pr.created_at").
+				Order("pr.created_at, pr.pull_request_id")
+		},
+		scan: func(db *gorm.DB, rows *sql.Rows) (exportRecord, error) {
+			var row struct {
+				PullRequestID   string
+				PullRequestName string
+				AuthorID        string
+				AuthorTeam      string
+				Status          string
+				CreatedAt       time.Time
+				MergedAt        *time.Time
+				Reviewers       string
+			}
+			if err := db.ScanRows(rows, &row); err != nil {
+				return nil, err
+			}
+			out := ExportPR{
+				PullRequestID:   row.PullRequestID,
+				PullRequestName: row.PullRequestName,
+				AuthorID:        row.AuthorID,
+				AuthorTeam:      row.AuthorTeam,
+				Status:          row.Status,
+				CreatedAt:       row.CreatedAt,
+				MergedAt:        row.MergedAt,
+				Reviewers:       []string{},
+			}
+			if row.Reviewers != "" {
+				out.Reviewers = strings.Split(row.Reviewers, ",")
+			}
+			return out, nil
+		},
+	},
+	"assignments": {
+		header: []string{"pull_request_id", "position", "reviewer_id", "reviewer_team", "author_id", "status",
+			"created_at", "merged_at"},
+		query: func(db *gorm.DB, q exportQuery) *gorm.DB {
+			return q.window(db.Table("pr_reviewers AS r").
+				Select(`r.pr_id AS pull_request_id, r.position, r.reviewer_id, u.team_name AS reviewer_team,
+					pr.author_id, pr.status, pr.created_at, pr.merged_at`).
+				Joins("JOIN pull_requests pr ON pr.pull_request_id = r.pr_id").
+				Joins("JOIN users u ON u.user_id = r.reviewer_id"), "pr.created_at").
+				Order("pr.created_at, r.pr_id, r.position")
+		},
+		scan: func(db *gorm.DB, rows *sql.Rows) (exportRecord, error) {
+			var row ExportAssignment
+			err := db.ScanRows(rows, &row)
+			return row, err
+		},
+	},
+	"events": {
+		header: []string{"id", "pull_request_id", "kind", "old_user_id", "new_user_id", "reason", "at"},
+		query: func(db *gorm.DB, q exportQuery) *gorm.DB {
+			return q.window(db.Table("pr_events AS e").
+				Select("e.id, e.pr_id AS pull_request_id, e.kind, e.old_user_id, e.new_user_id, e.reason, e.created_at AS at"),
+				"e.created_at").
+				Order("e.id")
+		},
+		scan: func(db *gorm.DB, rows *sql.Rows) (exportRecord, error) {
+			var row ExportEvent
+			err := db.ScanRows(rows, &row)
+			return row, err
+		},
+	},
+}
+
+// Export обрабатывает GET /export/{dataset}
+// GET /export/{pullRequests|assignments|events}?format=csv|ndjson&from=&to= -> 200 (поток) | 400 | 404
+//
+// Строки читаются курсором БД и пишутся клиенту по мере чтения, без загрузки в память.
+// Ошибка посреди потока уже не может сменить статус: пишем её в лог и обрываем ответ
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	name := chi.URLParam(r, "dataset")
+	ds, ok := exportDatasets[name]
+	if !ok {
+		writeErr(w, "NOT_FOUND", "unknown dataset (want pullRequests, assignments or events)", http.StatusNotFound)
+		return
+	}
+	in, err := parseExportQuery(r.URL.Query())
+	if err != nil {
+		writeErr(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := ds.query(db, in).Rows()
+	if err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	defer rows.Close()
+
+	// выгрузка может идти дольше WriteTimeout сервера
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	ext, ctype := "ndjson", "application/x-ndjson"
+	if in.Format == "csv" {
+		ext, ctype = "csv", "text/csv; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, ext))
+	w.WriteHeader(http.StatusOK)
+
+	var write func(exportRecord) error
+	var flush func() error
+	if in.Format == "csv" {
+		cw := csv.NewWriter(w)
+		_ = cw.Write(ds.header)
+		write = func(rec exportRecord) error { return cw.Write(rec.csvRow()) }
+		flush = func() error { cw.Flush(); return cw.Error() }
+	} else {
+		enc := json.NewEncoder(w)
+		write = func(rec exportRecord) error { return enc.Encode(rec) }
+		flush = func() error { return nil }
+	}
+
+	n := 0
+	for rows.Next() {
+		rec, err := ds.scan(db, rows)
+		if err == nil {
+			err = write(rec)
+		}
+		if err != nil {
+			h.exportAborted(r, name, n, err)
+			return
+		}
+		if n++; n%exportFlushEvery == 0 {
+			if err := flush(); err != nil {
+				h.exportAborted(r, name, n, err)
+				return
+			}
+			_ = rc.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		h.exportAborted(r, name, n, err)
+		return
+	}
+	if err := flush(); err != nil {
+		h.exportAborted(r, name, n, err)
+	}
+}
+
+func (h *Handler) exportAborted(r *http.Request, dataset string, written int, err error) {
+	h.logger(r).Error("export aborted", "dataset", dataset, "rows", written,
+		"client_gone", r.Context().Err() != nil, "err", err)
+}
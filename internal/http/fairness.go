@@ -0,0 +1,157 @@
+package httpapi
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alinaaved/pr-reviewer/internal/fairness"
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+// StatsFairness обрабатывает GET /stats/fairness
+// GET /stats/fairness?team_name=...&from=&to=&outlier_ratio=&format=json|csv -> 200 | 400
+//
+// Для каждого текущего участника команды сравнивает долю назначений за окно
+// с долей активного времени (по журналу user_activity) и считает Gini-индекс.
+// format=csv (или Accept: text/csv) — таблица участников для выгрузки
+func (h *Handler) StatsFairness(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	q := r.URL.Query()
+	in, err := parseStatsQuery(q)
+	if err != nil {
+		writeErr(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if in.Team == "" {
+		writeErr(w, "BAD_REQUEST", "team_name is required", http.StatusBadRequest)
+		return
+	}
+	ratio := fairness.DefaultOutlierRatio
+	if v := q.Get("outlier_ratio"); v != "" {
+		ratio, err = strconv.ParseFloat(v, 64)
+		if err != nil || ratio <= 1 {
+			writeErr(w, "BAD_REQUEST", "outlier_ratio must be a number > 1", http.StatusBadRequest)
+			return
+		}
+	}
+	format := q.Get("format")
+	if format == "" && r.Header.Get("Accept") == "text/csv" {
+		format = "csv"
+	}
+	if format != "" && format != "json" && format != "csv" {
+		writeErr(w, "BAD_REQUEST", "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+
+	var users []model.UserDB
+	if err := db.Where("team_name = ?", in.Team).Order("user_id").Find(&users).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	if len(users) == 0 {
+		writeErr(w, "NOT_FOUND", "team not found or empty", http.StatusNotFound)
+		return
+	}
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.UserID)
+	}
+
+	var counts []struct {
+		UserID string
+		Count  int64
+	}
+	if err := db.Table("pr_events").
+		Select("new_user_id AS user_id, COUNT(*) AS count").
+		Where("kind IN ? AND new_user_id IN ?", []string{model.EventAssigned, model.EventReassigned}, ids).
+		Where("created_at >= ? AND created_at < ?", in.From, in.To).
+		Group("new_user_id").
+		Scan(&counts).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	assigned := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		assigned[c.UserID] = c.Count
+	}
+
+	var log []model.UserActivityDB
+	if err := db.Where("user_id IN ? AND changed_at < ?", ids, in.To).
+		Order("user_id, changed_at, id").
+		Find(&log).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	changes := make(map[string][]fairness.Change, len(users))
+	for _, a := range log {
+		changes[a.UserID] = append(changes[a.UserID], fairness.Change{At: a.ChangedAt, Active: a.IsActive})
+	}
+
+	members := make([]fairness.Member, 0, len(users))
+	byID := make(map[string]model.UserDB, len(users))
+	for _, u := range users {
+		byID[u.UserID] = u
+		members = append(members, fairness.Member{
+			UserID:      u.UserID,
+			Active:      fairness.ActiveTime(changes[u.UserID], in.From, in.To),
+			Assignments: assigned[u.UserID],
+		})
+	}
+	rep := fairness.Build(members, ratio)
+
+	out := FairnessReport{
+		TeamName:         in.Team,
+		From:             in.From,
+		To:               in.To,
+		OutlierRatio:     ratio,
+		TotalAssignments: rep.TotalAssignments,
+		Gini:             rep.Gini,
+		Members:          make([]FairnessMember, 0, len(rep.Members)),
+	}
+	for _, m := range rep.Members {
+		u := byID[m.UserID]
+		out.Members = append(out.Members, FairnessMember{
+			UserID:          m.UserID,
+			Username:        u.Username,
+			IsActive:        u.IsActive,
+			ActiveHours:     m.Active.Hours(),
+			ActiveShare:     m.ActiveShare,
+			Assignments:     m.Assignments,
+			AssignmentShare: m.AssignmentShare,
+			LoadRatio:       m.LoadRatio,
+			Outlier:         m.Outlier,
+		})
+	}
+
+	if format == "csv" {
+		writeFairnessCSV(w, out)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// writeFairnessCSV пишет участников отчёта; team_name и gini повторяются в каждой строке,
+// чтобы файл был самодостаточным в таблицах
+func writeFairnessCSV(w http.ResponseWriter, rep FairnessReport) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="fairness-`+rep.TeamName+`.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"team_name", "from", "to", "gini", "user_id", "username", "is_active",
+		"active_hours", "active_share", "assignments", "assignment_share", "load_ratio", "outlier",
+	})
+	for _, m := range rep.Members {
+		_ = cw.Write([]string{
+			rep.TeamName, rep.From.Format(time.RFC3339), rep.To.Format(time.RFC3339), f(rep.Gini),
+			m.UserID, m.Username, strconv.FormatBool(m.IsActive),
+			strconv.FormatFloat(m.ActiveHours, 'f', 1, 64), f(m.ActiveShare),
+			strconv.FormatInt(m.Assignments, 10), f(m.AssignmentShare), f(m.LoadRatio), m.Outlier,
+		})
+	}
+	cw.Flush()
+}
@@ -0,0 +1,99 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+// PRGet обрабатывает GET /pullRequest/get
+// GET /pullRequest/get?pull_request_id=... -> 200 {pr:{..., reviewers:[...], history:{...}}} + ETag
+// 304 (If-None-Match совпал) | 400 | 404 NOT_FOUND
+func (h *Handler) PRGet(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	id := r.URL.Query().Get("pull_request_id")
+	if id == "" {
+		writeErr(w, "BAD_REQUEST", "pull_request_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var pr model.PullRequestDB
+	if err := db.First(&pr, "pull_request_id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeErr(w, "NOT_FOUND", "PR not found", http.StatusNotFound)
+			return
+		}
+		h.dbErr(w, r, err)
+		return
+	}
+	base, err := h.buildPR(db, pr)
+	if err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+
+	// данные ревьюверов в порядке слотов
+	var users []model.UserDB
+	if len(base.Assigned) > 0 {
+		if err := db.Where("user_id IN ?", base.Assigned).Find(&users).Error; err != nil {
+			h.dbErr(w, r, err)
+			return
+		}
+	}
+	byID := make(map[string]model.UserDB, len(users))
+	for _, u := range users {
+		byID[u.UserID] = u
+	}
+	reviewers := make([]User, 0, len(base.Assigned))
+	for _, uid := range base.Assigned {
+		u := byID[uid]
+		reviewers = append(reviewers, User{
+			UserID:         u.UserID,
+			Username:       u.Username,
+			TeamName:       u.TeamName,
+			IsActive:       u.IsActive,
+			Level:          u.Level,
+			MaxOpenReviews: u.MaxOpenReviews,
+		})
+	}
+
+	hist, err := loadHistory(db, pr.ID)
+	if err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+
+	body, _ := json.Marshal(map[string]any{"pr": PullRequestDetail{
+		PullRequest: base,
+		Reviewers:   reviewers,
+		History:     hist,
+	}})
+	// ETag — версия PR, как у изменяющих вызовов: годится для If-Match. Версия растёт при изменении
+	// ревьюверов и merge; профили ревьюверов и неудачные попытки переназначения её не меняют
+	etag := prETag(pr.Version)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(append(body, '\n'))
+}
+
+// etagMatch проверяет заголовок If-None-Match (список ETag или *)
+func etagMatch(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+		if v == "*" || v == etag {
+			return true
+		}
+	}
+	return false
+}
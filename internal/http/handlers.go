@@ -3,19 +3,82 @@ package httpapi
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
+	"github.com/alinaaved/pr-reviewer/internal/auth"
+	"github.com/alinaaved/pr-reviewer/internal/metrics"
 	"github.com/alinaaved/pr-reviewer/internal/model"
+	"github.com/alinaaved/pr-reviewer/internal/selector"
 )
 
 // Handler инкапсулирует зависимости HTTP-слоя (БД и т.п.)
-type Handler struct{ db *gorm.DB }
+type Handler struct {
+	db      *gorm.DB
+	auth    auth.Authenticator
+	log     *slog.Logger
+	metrics *metrics.Metrics
+
+	sel       selector.Strategy
+	reviewers int  // сколько ревьюверов назначать на PR (0..2)
+	testMode  bool // принимать зерно выбора из X-Selector-Seed
+
+	idemTTL time.Duration // сколько хранить ответы по Idempotency-Key
+}
+
+// Option настраивает Handler
+type Option func(*Handler)
+
+// WithAuthenticator включает аутентификацию запросов
+func WithAuthenticator(a auth.Authenticator) Option {
+	return func(h *Handler) { h.auth = a }
+}
+
+// WithLogger задаёт логгер (по умолчанию slog.Default())
+func WithLogger(l *slog.Logger) Option {
+	return func(h *Handler) { h.log = l }
+}
+
+// WithMetrics включает сбор Prometheus-метрик и маршрут /metrics
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(h *Handler) { h.metrics = m }
+}
+
+// WithSelector задаёт стратегию выбора и число ревьюверов на PR (0..2)
+func WithSelector(s selector.Strategy, reviewers int) Option {
+	return func(h *Handler) { h.sel, h.reviewers = s, reviewers }
+}
+
+// WithTestMode разрешает задавать зерно выбора ревьюверов на запрос заголовком X-Selector-Seed,
+// чтобы назначения в тестах были воспроизводимы. В рабочем окружении не включать
+func WithTestMode() Option {
+	return func(h *Handler) { h.testMode = true }
+}
+
+// WithIdempotencyTTL задаёт срок хранения ответов по Idempotency-Key (по умолчанию 24 часа)
+func WithIdempotencyTTL(d time.Duration) Option {
+	return func(h *Handler) { h.idemTTL = d }
+}
 
 // NewHandler создаёт новый Handler
-func NewHandler(db *gorm.DB) *Handler { return &Handler{db: db} }
+func NewHandler(db *gorm.DB, opts ...Option) *Handler {
+	h := &Handler{
+		db:        db,
+		log:       slog.Default(),
+		sel:       selector.NewRandom(),
+		reviewers: 2,
+		idemTTL:   24 * time.Hour,
+	}
+	for _, o := range opts {
+		o(h)
+	}
+	return h
+}
 
 func writeJSON(w http.ResponseWriter, code int, v any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -28,14 +91,21 @@ func writeErr(w http.ResponseWriter, code, msg string, status int) {
 	writeJSON(w, status, resp)
 }
 
+// dbErr логирует ошибку БД и отвечает клиенту 500 без подробностей
+func (h *Handler) dbErr(w http.ResponseWriter, r *http.Request, err error) {
+	h.logger(r).Error("db error", "err", err)
+	writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+}
+
 // Healthz возвращает 200 OK для проверки живости сервиса
 func (h *Handler) Healthz(w http.ResponseWriter, _ *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
 // TeamAdd обрабатывает POST /team/add
-// POST /team/add -> 201 {team:{...}} | 400 TEAM_EXISTS
+// POST /team/add -> 201 {team:{...}} | 400 TEAM_EXISTS | 403
 func (h *Handler) TeamAdd(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
 	var in Team
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
 		writeErr(w, "BAD_REQUEST", "invalid json", http.StatusBadRequest)
@@ -45,40 +115,82 @@ func (h *Handler) TeamAdd(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, "BAD_REQUEST", "team_name is required", http.StatusBadRequest)
 		return
 	}
-
-	// по контракту — если уже есть, вернуть 400 TEAM_EXISTS
-	var cnt int64
-	if err := h.db.Model(&model.TeamDB{}).
-		Where("team_name = ?", in.TeamName).Count(&cnt).Error; err != nil {
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+	if err := h.validateComposition(in); err != nil {
+		writeErr(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
 		return
 	}
-	if cnt > 0 {
-		writeErr(w, "TEAM_EXISTS", "team_name already exists", http.StatusBadRequest)
+	if !h.allowTeam(w, r, in.TeamName) {
 		return
 	}
 
-	// создаем команду
-	if err := h.db.Create(&model.TeamDB{TeamName: in.TeamName}).Error; err != nil {
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
-		return
-	}
+	// команда и участники пишутся одной транзакцией: отказ по любому участнику откатывает всё
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// по контракту — если уже есть, вернуть 400 TEAM_EXISTS
+		var cnt int64
+		if err := tx.Model(&model.TeamDB{}).
+			Where("team_name = ?", in.TeamName).Count(&cnt).Error; err != nil {
+			return err
+		}
+		if cnt > 0 {
+			writeErr(w, "TEAM_EXISTS", "team_name already exists", http.StatusBadRequest)
+			return errStop
+		}
 
-	// upsert участников
-	for _, m := range in.Members {
-		u := model.UserDB{
-			UserID:   m.UserID,
-			Username: m.Username,
-			IsActive: m.IsActive,
-			TeamName: in.TeamName,
+		// создаем команду
+		team := model.TeamDB{TeamName: in.TeamName, MinSeniorReviewers: int16(in.MinSeniorReviewers), MaxOpenReviews: in.MaxOpenReviews}
+		if err := tx.Create(&team).Error; err != nil {
+			return err
 		}
-		if err := h.db.Clauses(clause.OnConflict{
-			Columns:   []clause.Column{{Name: "user_id"}},
-			DoUpdates: clause.AssignmentColumns([]string{"username", "is_active", "team_name"}),
-		}).Create(&u).Error; err != nil {
-			writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
-			return
+
+		// upsert участников
+		for _, m := range in.Members {
+			var cur []model.UserDB
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("user_id = ?", m.UserID).Limit(1).Find(&cur).Error; err != nil {
+				return err
+			}
+			// перевод из другой команды меняет и её состав — нужен доступ к обеим
+			if len(cur) > 0 && cur[0].TeamName != in.TeamName {
+				if msg := teamDenied(r, cur[0].TeamName); msg != "" {
+					writeErr(w, "FORBIDDEN", fmt.Sprintf("user %s: %s", m.UserID, msg), http.StatusForbidden)
+					return errStop
+				}
+			}
+			u := model.UserDB{
+				UserID:         m.UserID,
+				Username:       m.Username,
+				IsActive:       m.Active(),
+				TeamName:       in.TeamName,
+				Level:          m.Level,
+				MaxOpenReviews: m.MaxOpenReviews,
+			}
+			// уровень и лимит не указаны — у нового пользователя по умолчанию, у существующего прежние
+			cols := []string{"username", "is_active", "team_name"}
+			if m.Level != "" {
+				cols = append(cols, "level")
+			}
+			if m.MaxOpenReviews != nil {
+				cols = append(cols, "max_open_reviews")
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}},
+				DoUpdates: clause.AssignmentColumns(cols),
+			}).Create(&u).Error; err != nil {
+				return err
+			}
+			if len(cur) == 0 || cur[0].IsActive != m.Active() {
+				if err := recordActivity(tx, m.UserID, m.Active()); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, errStop) {
+			h.dbErr(w, r, err)
 		}
+		return
 	}
 
 	writeJSON(w, http.StatusCreated, map[string]any{"team": in})
@@ -87,35 +199,37 @@ func (h *Handler) TeamAdd(w http.ResponseWriter, r *http.Request) {
 // TeamGet обрабатывает GET /team/get
 // GET /team/get?team_name=... -> 200 (голый Team) | 404
 func (h *Handler) TeamGet(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
 	name := r.URL.Query().Get("team_name")
 	if name == "" {
 		writeErr(w, "BAD_REQUEST", "team_name is required", http.StatusBadRequest)
 		return
 	}
 
-	var exists int64
-	if err := h.db.Model(&model.TeamDB{}).
-		Where("team_name = ?", name).Count(&exists).Error; err != nil {
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
-		return
-	}
-	if exists == 0 {
-		writeErr(w, "NOT_FOUND", "team not found", http.StatusNotFound)
+	var team model.TeamDB
+	if err := db.First(&team, "team_name = ?", name).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeErr(w, "NOT_FOUND", "team not found", http.StatusNotFound)
+			return
+		}
+		h.dbErr(w, r, err)
 		return
 	}
 
 	var users []model.UserDB
-	if err := h.db.Where("team_name = ?", name).Find(&users).Error; err != nil {
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+	if err := db.Where("team_name = ?", name).Find(&users).Error; err != nil {
+		h.dbErr(w, r, err)
 		return
 	}
 
-	out := Team{TeamName: name}
+	out := Team{TeamName: name, MinSeniorReviewers: int(team.MinSeniorReviewers), MaxOpenReviews: team.MaxOpenReviews}
 	for _, u := range users {
 		out.Members = append(out.Members, TeamMember{
-			UserID:   u.UserID,
-			Username: u.Username,
-			IsActive: u.IsActive,
+			UserID:         u.UserID,
+			Username:       u.Username,
+			IsActive:       &u.IsActive,
+			Level:          u.Level,
+			MaxOpenReviews: u.MaxOpenReviews,
 		})
 	}
 	writeJSON(w, http.StatusOK, out)
@@ -124,6 +238,7 @@ func (h *Handler) TeamGet(w http.ResponseWriter, r *http.Request) {
 // UsersSetIsActive обрабатывает POST /users/setIsActive
 // POST /users/setIsActive -> 200 {user:{...}} | 404
 func (h *Handler) UsersSetIsActive(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
 	var in struct {
 		UserID   string `json:"user_id"`
 		IsActive bool   `json:"is_active"`
@@ -134,84 +249,144 @@ func (h *Handler) UsersSetIsActive(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var u model.UserDB
-	if err := h.db.First(&u, "user_id = ?", in.UserID).Error; err != nil {
+	if err := db.First(&u, "user_id = ?", in.UserID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			writeErr(w, "NOT_FOUND", "user not found", http.StatusNotFound)
 			return
 		}
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+		h.dbErr(w, r, err)
+		return
+	}
+	if !h.allowTeam(w, r, u.TeamName) {
 		return
 	}
 
-	if err := h.db.Model(&u).Update("is_active", in.IsActive).Error; err != nil {
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&u).Update("is_active", in.IsActive).Error; err != nil {
+			return err
+		}
+		if u.IsActive == in.IsActive {
+			return nil
+		}
+		return recordActivity(tx, u.UserID, in.IsActive)
+	}); err != nil {
+		h.dbErr(w, r, err)
 		return
 	}
 	u.IsActive = in.IsActive
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"user": User{
-			UserID:   u.UserID,
-			Username: u.Username,
-			TeamName: u.TeamName,
-			IsActive: u.IsActive,
+			UserID:         u.UserID,
+			Username:       u.Username,
+			TeamName:       u.TeamName,
+			IsActive:       u.IsActive,
+			Level:          u.Level,
+			MaxOpenReviews: u.MaxOpenReviews,
 		},
 	})
 }
 
 // UsersGetReview обрабатывает GET /users/getReview
-// GET /users/getReview?user_id=... -> 200 { user_id, pull_requests:[...] }
+// GET /users/getReview?user_id=...&status=OPEN|MERGED|ALL&limit=&cursor=&include=age
+// -> 200 { user_id, pull_requests:[...], next_cursor } | 400
+//
+// По умолчанию только OPEN, новые сверху. include=age добавляет,
+// когда пользователь назначен и сколько ревью висит (до merge)
 func (h *Handler) UsersGetReview(w http.ResponseWriter, r *http.Request) {
-	uid := r.URL.Query().Get("user_id")
-	if uid == "" {
-		writeErr(w, "BAD_REQUEST", "user_id is required", http.StatusBadRequest)
+	db := h.db.WithContext(r.Context())
+	in, err := parseReviewQuery(r.URL.Query())
+	if err != nil {
+		writeErr(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	type row struct {
-		ID     string
-		Name   string
-		Author string
-		Status string
-	}
-	var rows []row
-	if err := h.db.
+		ID         string
+		Name       string
+		Author     string
+		Status     string
+		CreatedAt  time.Time
+		MergedAt   *time.Time
+		AssignedAt *time.Time
+	}
+	cols := "pr.pull_request_id AS id, pr.pull_request_name AS name, pr.author_id AS author, pr.status, pr.created_at, pr.merged_at"
+	if in.Age {
+		// последнее назначение: при создании PR, позже — переназначением или setReviewers
+		cols += `, COALESCE((SELECT max(e.created_at) FROM pr_events e
+			WHERE e.pr_id = pr.pull_request_id AND e.kind IN ('ASSIGNED', 'REASSIGNED') AND e.new_user_id = r.reviewer_id),
+			pr.created_at) AS assigned_at`
+	}
+	q := db.
 		Table("pr_reviewers AS r").
-		Select("pr.pull_request_id AS id, pr.pull_request_name AS name, pr.author_id AS author, pr.status").
+		Select(cols).
 		Joins("JOIN pull_requests pr ON pr.pull_request_id = r.pr_id").
-		Where("r.reviewer_id = ?", uid).
+		Where("r.reviewer_id = ?", in.UserID)
+	if in.Status != "ALL" {
+		q = q.Where("pr.status = ?", in.Status)
+	}
+	if in.After != nil {
+		at, _ := time.Parse(time.RFC3339Nano, in.After.Value)
+		q = q.Where("(pr.created_at, pr.pull_request_id) < (?, ?)", at, in.After.ID)
+	}
+	var rows []row
+	if err := q.
 		Order("pr.created_at DESC").
+		Order("pr.pull_request_id DESC").
+		Limit(in.Limit + 1).
 		Scan(&rows).Error; err != nil {
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+		h.dbErr(w, r, err)
 		return
 	}
+	var next string
+	if len(rows) > in.Limit {
+		rows = rows[:in.Limit]
+		last := rows[len(rows)-1]
+		next = encodeCursor(listCursor{Value: last.CreatedAt.Format(time.RFC3339Nano), ID: last.ID})
+	}
 
-	list := make([]PullRequestShort, 0, len(rows))
+	now := time.Now()
+	list := make([]ReviewItem, 0, len(rows))
 	for _, x := range rows {
-		list = append(list, PullRequestShort{
+		it := ReviewItem{PullRequestShort: PullRequestShort{
 			PullRequestID:   x.ID,
 			PullRequestName: x.Name,
 			AuthorID:        x.Author,
 			Status:          x.Status,
-		})
+		}}
+		if in.Age && x.AssignedAt != nil {
+			end := now
+			if x.MergedAt != nil {
+				end = *x.MergedAt
+			}
+			age := int64(end.Sub(*x.AssignedAt).Seconds())
+			it.AssignedAt = x.AssignedAt
+			it.AgeSeconds = &age
+		}
+		list = append(list, it)
 	}
 	writeJSON(w, http.StatusOK, map[string]any{
-		"user_id":       uid,
+		"user_id":       in.UserID,
 		"pull_requests": list,
+		"next_cursor":   next,
 	})
 }
 
-func (h *Handler) buildPR(pr model.PullRequestDB) (PullRequest, error) {
+func (h *Handler) buildPR(db *gorm.DB, pr model.PullRequestDB) (PullRequest, error) {
 	var rows []model.PRReviewerDB
-	if err := h.db.
+	if err := db.
 		Where("pr_id = ?", pr.ID).
 		Order("position ASC").
 		Find(&rows).Error; err != nil {
 		return PullRequest{}, err
 	}
 	assigned := make([]string, 0, len(rows))
+	var pinned []string
 	for _, r := range rows {
 		assigned = append(assigned, r.ReviewerID)
+		if r.Pinned {
+			pinned = append(pinned, r.ReviewerID)
+		}
 	}
 	out := PullRequest{
 		PullRequestID:   pr.ID,
@@ -219,20 +394,27 @@ func (h *Handler) buildPR(pr model.PullRequestDB) (PullRequest, error) {
 		AuthorID:        pr.AuthorID,
 		Status:          pr.Status,
 		Assigned:        assigned,
+		Pinned:          pinned,
 		CreatedAt:       &pr.CreatedAt,
 		MergedAt:        pr.MergedAt,
+		Version:         pr.Version,
 	}
 	return out, nil
 }
 
 // PRCreate обрабатывает POST /pullRequest/create
 // POST /pullRequest/create
-// 201 {pr:{...}} | 404 NOT_FOUND (нет автора/команды) | 409 PR_EXISTS
+// 201 {pr:{...}} | 400 | 404 NOT_FOUND (нет автора/команды/ревьювера) | 409 PR_EXISTS | REVIEWER_INACTIVE | REVIEWER_EXCLUDED
+//
+// requested_reviewers — ревьюверы, выбранные автором: занимают первые слоты и закрепляются
+// (pinned), остальные слоты заполняются автоматически из команды автора
 func (h *Handler) PRCreate(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
 	var in struct {
-		ID   string `json:"pull_request_id"`
-		Name string `json:"pull_request_name"`
-		Auth string `json:"author_id"`
+		ID        string   `json:"pull_request_id"`
+		Name      string   `json:"pull_request_name"`
+		Auth      string   `json:"author_id"`
+		Requested []string `json:"requested_reviewers"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
 		writeErr(w, "BAD_REQUEST", "invalid json", http.StatusBadRequest)
@@ -240,29 +422,42 @@ func (h *Handler) PRCreate(w http.ResponseWriter, r *http.Request) {
 	}
 	// 1) проверка автора
 	var author model.UserDB
-	if err := h.db.First(&author, "user_id = ?", in.Auth).Error; err != nil {
+	if err := db.First(&author, "user_id = ?", in.Auth).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			writeErr(w, "NOT_FOUND", "author not found", http.StatusNotFound)
 			return
 		}
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+		h.dbErr(w, r, err)
+		return
+	}
+	if !h.allowTeam(w, r, author.TeamName) {
 		return
 	}
 	// 2) защита от дубля PR
 	var cnt int64
-	if err := h.db.Model(&model.PullRequestDB{}).
+	if err := db.Model(&model.PullRequestDB{}).
 		Where("pull_request_id = ?", in.ID).
 		Count(&cnt).Error; err != nil {
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+		h.dbErr(w, r, err)
 		return
 	}
 	if cnt > 0 {
 		writeErr(w, "PR_EXISTS", "PR id already exists", http.StatusConflict)
 		return
 	}
+	if err := h.checkRequested(db, in.Auth, in.Requested); err != nil {
+		var re *reviewerError
+		if errors.As(err, &re) {
+			re.write(w)
+			return
+		}
+		h.dbErr(w, r, err)
+		return
+	}
 
-	// 3) транзакция: вставка PR + назначение до двух активных ревьюверов из команды автора
-	if err := h.db.Transaction(func(tx *gorm.DB) error {
+	// 3) транзакция: вставка PR + назначение до h.reviewers активных ревьюверов из команды автора
+	assigned := 0
+	if err := db.Transaction(func(tx *gorm.DB) error {
 		pr := model.PullRequestDB{
 			ID:       in.ID,
 			Name:     in.Name,
@@ -273,48 +468,60 @@ func (h *Handler) PRCreate(w http.ResponseWriter, r *http.Request) {
 			return err
 		}
 
-		// кандидаты: активные из команды автора, не автор; случайный порядок; максимум 2
-		type cand struct{ ID string }
-		var cands []cand
-		if err := tx.
-			Table("users").
-			Select("user_id AS id").
-			Where("team_name = ? AND is_active = TRUE AND user_id <> ?", author.TeamName, in.Auth).
-			Order("random()").
-			Limit(2).
-			Scan(&cands).Error; err != nil {
+		// сначала запрошенные автором, остальные — по правилам и из команды автора, не автор;
+		// выбирает стратегия, всего не больше h.reviewers
+		ids, err := h.pickReviewers(tx, h.strategy(r), author, in.Requested, nil)
+		if err != nil {
+			return err
+		}
+		if err := recordEvent(tx, pr.ID, model.EventCreated, "", ""); err != nil {
 			return err
 		}
-		for i, c := range cands {
-			rec := model.PRReviewerDB{PRID: pr.ID, ReviewerID: c.ID, Position: int16(i + 1)}
+		for i, id := range ids {
+			rec := model.PRReviewerDB{PRID: pr.ID, ReviewerID: id, Position: int16(i + 1), Pinned: i < len(in.Requested)}
 			if err := tx.Create(&rec).Error; err != nil {
 				return err
 			}
+			if err := recordEvent(tx, pr.ID, model.EventAssigned, "", id); err != nil {
+				return err
+			}
+		}
+		// незаполненные слоты — для статистики NO_CANDIDATE
+		for i := len(ids); i < h.reviewers; i++ {
+			if err := recordEvent(tx, pr.ID, model.EventNoCandidate, "", ""); err != nil {
+				return err
+			}
 		}
+		assigned = len(ids)
 		return nil
 	}); err != nil {
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+		h.dbErr(w, r, err)
 		return
 	}
+	h.metrics.PRCreated(h.reviewers - assigned)
 
 	// 4) собрать и отдать ответ 201
 	var saved model.PullRequestDB
-	if err := h.db.First(&saved, "pull_request_id = ?", in.ID).Error; err != nil {
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+	if err := db.First(&saved, "pull_request_id = ?", in.ID).Error; err != nil {
+		h.dbErr(w, r, err)
 		return
 	}
-	out, err := h.buildPR(saved)
+	out, err := h.buildPR(db, saved)
 	if err != nil {
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+		h.dbErr(w, r, err)
 		return
 	}
+	w.Header().Set("ETag", prETag(out.Version))
 	writeJSON(w, http.StatusCreated, map[string]any{"pr": out})
 }
 
 // PRMerge обрабатывает POST /pullRequest/merge (идемпотентно)
 // POST /pullRequest/merge — идемпотентно
-// 200 {pr:{...}} | 404 NOT_FOUND
+// 200 {pr:{...}} + ETag | 404 NOT_FOUND | 412 PRECONDITION_FAILED (If-Match не совпал с версией открытого PR)
+//
+// Уже смердженный PR возвращается как есть, без проверки If-Match: повтор merge не ошибка
 func (h *Handler) PRMerge(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
 	var in struct {
 		ID string `json:"pull_request_id"`
 	}
@@ -323,36 +530,71 @@ func (h *Handler) PRMerge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var pr model.PullRequestDB
-	if err := h.db.First(&pr, "pull_request_id = ?", in.ID).Error; err != nil {
+	if err := db.First(&pr, "pull_request_id = ?", in.ID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			writeErr(w, "NOT_FOUND", "PR not found", http.StatusNotFound)
 			return
 		}
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+		h.dbErr(w, r, err)
+		return
+	}
+	if !h.allowUserTeam(w, r, db, pr.AuthorID) {
 		return
 	}
 	if pr.Status != "MERGED" { // идемпотентность
-		if err := h.db.Model(&pr).
-			Updates(map[string]any{"status": "MERGED", "merged_at": gorm.Expr("now()")}).
-			Error; err != nil {
-			writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+		// PR блокируется: merge не пересекается с переназначением того же PR.
+		// Событие пишем, только если именно этот запрос перевёл PR в MERGED
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			locked, err := lockPR(tx, pr.ID)
+			if err != nil || locked.Status == "MERGED" {
+				return err
+			}
+			if !ifMatch(r, locked.Version) {
+				writePreconditionFailed(w, locked.Version)
+				return errStop
+			}
+			if err := tx.Model(&model.PullRequestDB{}).
+				Where("pull_request_id = ?", pr.ID).
+				Updates(map[string]any{
+					"status":    "MERGED",
+					"merged_at": gorm.Expr("now()"),
+					"version":   gorm.Expr("version + 1"),
+				}).Error; err != nil {
+				return err
+			}
+			return recordEvent(tx, pr.ID, model.EventMerged, "", "")
+		}); err != nil {
+			if !errors.Is(err, errStop) {
+				h.dbErr(w, r, err)
+			}
 			return
 		}
-		_ = h.db.First(&pr, "pull_request_id = ?", in.ID).Error
+		if err := db.First(&pr, "pull_request_id = ?", in.ID).Error; err != nil {
+			h.dbErr(w, r, err)
+			return
+		}
+		if pr.MergedAt != nil {
+			h.metrics.PRMerged(pr.CreatedAt, *pr.MergedAt)
+		}
 	}
-	out, err := h.buildPR(pr)
+	out, err := h.buildPR(db, pr)
 	if err != nil {
-		writeErr(w, "INTERNAL", "db error", http.StatusInternalServerError)
+		h.dbErr(w, r, err)
 		return
 	}
+	w.Header().Set("ETag", prETag(out.Version))
 	writeJSON(w, http.StatusOK, map[string]any{"pr": out})
 }
 
 // PRReassign обрабатывает POST /pullRequest/reassign
 // POST /pullRequest/reassign
-// { pull_request_id, old_user_id } -> 200 { pr:{...}, replaced_by:"uX" }
-// 404 NOT_FOUND, 409 PR_MERGED | NOT_ASSIGNED | NO_CANDIDATE
+// { pull_request_id, old_user_id } -> 200 { pr:{...}, replaced_by:"uX" } + ETag
+// 404 NOT_FOUND, 409 PR_MERGED | NOT_ASSIGNED | NO_CANDIDATE | AT_CAPACITY, 412 PRECONDITION_FAILED (If-Match)
+//
+// PR блокируется до конца транзакции: параллельные переназначения и merge того же PR
+// выполняются по очереди, второй видит результат первого
 func (h *Handler) PRReassign(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
 	var in struct {
 		PRID    string `json:"pull_request_id"`
 		OldUser string `json:"old_user_id"`
@@ -364,21 +606,32 @@ func (h *Handler) PRReassign(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// В транзакции, чтобы "увидеть" апдейт при последующем чтении.
-	err := h.db.Transaction(func(tx *gorm.DB) error {
+	// Ответ пишется после коммита: иначе Idempotent сохранил бы успех откатившейся транзакции
+	noCandidate := false
+	var out map[string]any
+	var version int64
+	err := db.Transaction(func(tx *gorm.DB) error {
 		// 1) PR существует и открыт
-		var pr model.PullRequestDB
-		if err := tx.First(&pr, "pull_request_id = ?", in.PRID).Error; err != nil {
+		pr, err := lockPR(tx, in.PRID)
+		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				writeErr(w, "NOT_FOUND", "PR not found", http.StatusNotFound)
 				return errStop // см. ниже локальная ошибка для раннего выхода
 			}
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"message": "db error"})
+			h.dbErr(w, r, err)
+			return errStop
+		}
+		if !h.allowUserTeam(w, r, tx, pr.AuthorID) {
 			return errStop
 		}
 		if pr.Status == "MERGED" {
 			writeErr(w, "PR_MERGED", "cannot reassign on merged PR", http.StatusConflict)
 			return errStop
 		}
+		if !ifMatch(r, pr.Version) {
+			writePreconditionFailed(w, pr.Version)
+			return errStop
+		}
 
 		// 2) Проверить, что old_user назначен и достать его слот (position)
 		var slot model.PRReviewerDB
@@ -387,7 +640,7 @@ func (h *Handler) PRReassign(w http.ResponseWriter, r *http.Request) {
 				writeErr(w, "NOT_ASSIGNED", "reviewer is not assigned to this PR", http.StatusConflict)
 				return errStop
 			}
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"message": "db error"})
+			h.dbErr(w, r, err)
 			return errStop
 		}
 
@@ -398,42 +651,24 @@ func (h *Handler) PRReassign(w http.ResponseWriter, r *http.Request) {
 			return errStop
 		}
 
-		// 4) Найти второго текущего ревьювера (если есть)
-		var other []string
-		if err := tx.Table("pr_reviewers").
-			Select("reviewer_id").
-			Where("pr_id = ? AND position <> ?", in.PRID, slot.Position).
-			Scan(&other).Error; err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"message": "db error"})
-			return errStop
-		}
-
-		// 5) Кандидат: активный из команды oldUser, не автор, не второй текущий, не oldUser
-		q := tx.Table("users").Select("user_id").
-			Where("team_name = ? AND is_active = TRUE", oldUser.TeamName).
-			Where("user_id <> ? AND user_id <> ?", in.OldUser, pr.AuthorID)
-		if len(other) > 0 {
-			q = q.Where("user_id <> ?", other[0])
-		}
-		var newID string
-		if err := q.Order("random()").Limit(1).Scan(&newID).Error; err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"message": "db error"})
+		// 4) Замена: активный из команды oldUser, не автор, не второй текущий, не oldUser
+		newID, err := h.replaceReviewer(tx, h.strategy(r), pr, slot, oldUser.TeamName, "")
+		if errors.Is(err, errAtCapacity) {
+			noCandidate = true
+			writeErr(w, "AT_CAPACITY", "all replacement candidates are at max_open_reviews", http.StatusConflict)
 			return errStop
 		}
-		if newID == "" {
+		if errors.Is(err, errNoCandidate) {
+			noCandidate = true
 			writeErr(w, "NO_CANDIDATE", "no active replacement candidate in team", http.StatusConflict)
 			return errStop
 		}
-
-		// 6) Апдейтим конкретный слот по position (важно!)
-		if err := tx.Model(&model.PRReviewerDB{}).
-			Where("pr_id = ? AND position = ?", in.PRID, slot.Position).
-			Update("reviewer_id", newID).Error; err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"message": "db error"})
+		if err != nil {
+			h.dbErr(w, r, err)
 			return errStop
 		}
 
-		// 7) Читаем обновлённый состав (после апдейта)
+		// 5) Читаем обновлённый состав (после апдейта)
 		type row struct{ ReviewerID string }
 		var rows []row
 		if err := tx.Table("pr_reviewers").
@@ -441,7 +676,7 @@ func (h *Handler) PRReassign(w http.ResponseWriter, r *http.Request) {
 			Where("pr_id = ?", in.PRID).
 			Order("position").
 			Scan(&rows).Error; err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"message": "db error"})
+			h.dbErr(w, r, err)
 			return errStop
 		}
 		assigned := make([]string, 0, len(rows))
@@ -449,8 +684,10 @@ func (h *Handler) PRReassign(w http.ResponseWriter, r *http.Request) {
 			assigned = append(assigned, r.ReviewerID)
 		}
 
-		// 8) Готовим ответ по контракту
-		writeJSON(w, http.StatusOK, map[string]any{
+		// 6) Готовим ответ по контракту; replaceReviewer уже увеличил версию
+		pr.Version++
+		version = pr.Version
+		out = map[string]any{
 			"pr": map[string]any{
 				"pull_request_id":    pr.ID,
 				"pull_request_name":  pr.Name,
@@ -459,17 +696,30 @@ func (h *Handler) PRReassign(w http.ResponseWriter, r *http.Request) {
 				"assigned_reviewers": assigned,
 				"createdAt":          pr.CreatedAt,
 				"mergedAt":           pr.MergedAt,
+				"version":            pr.Version,
 			},
 			"replaced_by": newID,
-		})
+		}
 		return nil
 	})
 
-	if err == nil {
+	if noCandidate {
+		// транзакция откатилась, неудачную попытку пишем в историю отдельно
+		h.metrics.NoCandidate("reassign")
+		if err := recordEvent(db, in.PRID, model.EventNoCandidate, in.OldUser, ""); err != nil {
+			h.logger(r).Error("record no-candidate event", "err", err)
+		}
+	}
+	if err != nil {
+		// errStop — локальная заглушка, чтобы «выйти» после writeErr: ответ уже отправлен
+		if !errors.Is(err, errStop) {
+			h.dbErr(w, r, err)
+		}
 		return
 	}
-	// errStop — локальная заглушка, чтобы «выйти» после writeJSON/writeErr
-	// сами ответы уже отправлены, тут просто завершаем
+	h.metrics.Reassigned()
+	w.Header().Set("ETag", prETag(version))
+	writeJSON(w, http.StatusOK, out)
 }
 
 var errStop = errors.New("stop")
@@ -477,19 +727,16 @@ var errStop = errors.New("stop")
 // StatsAssignmentsByUser возвращает агрегацию назначений по пользователям
 // GET /stats/assignments-by-user
 // 200 { "items": [ {"user_id":"u2","count":3}, ... ] }
-func (h *Handler) StatsAssignmentsByUser(w http.ResponseWriter, _ *http.Request) {
-	type row struct {
-		UserID string `json:"user_id"`
-		Count  int64  `json:"count"`
-	}
-	var rows []row
-	if err := h.db.
+func (h *Handler) StatsAssignmentsByUser(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	var rows []AssignmentCount
+	if err := db.
 		Table("pr_reviewers").
 		Select("reviewer_id AS user_id, COUNT(*) AS count").
 		Group("reviewer_id").
 		Order("count DESC").
 		Scan(&rows).Error; err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db"})
+		h.dbErr(w, r, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"items": rows})
@@ -3,17 +3,23 @@ package httpapi_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
-	"github.com/go-chi/chi/v5"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	"github.com/alinaaved/pr-reviewer/internal/auth"
 	api "github.com/alinaaved/pr-reviewer/internal/http"
+	"github.com/alinaaved/pr-reviewer/internal/model"
+	"github.com/alinaaved/pr-reviewer/internal/selector"
 )
 
 // Берем DSN из окружения, иначе локальный по умолчанию
@@ -43,33 +49,24 @@ func mustNewDB(t *testing.T) *gorm.DB {
 func truncateAll(t *testing.T, db *gorm.DB) {
 	t.Helper()
 	// порядок важен из-за FK, CASCADE чистит зависимые таблицы
-	if err := db.Exec(`TRUNCATE pr_reviewers, pull_requests, users, teams RESTART IDENTITY CASCADE`).Error; err != nil {
+	if err := db.Exec(`TRUNCATE pr_reviewers, pull_requests, users, teams, idempotency_keys RESTART IDENTITY CASCADE`).Error; err != nil {
 		t.Fatalf("truncate: %v", err)
 	}
 }
 
-func mustNewServer(t *testing.T, db *gorm.DB) *httptest.Server {
+func mustNewServer(t *testing.T, db *gorm.DB, opts ...api.Option) *httptest.Server {
 	t.Helper()
-	h := api.NewHandler(db)
-	r := chi.NewRouter()
-
 	// маршруты как в openapi.yml
-	r.Get("/healthz", h.Healthz)
-
-	r.Post("/team/add", h.TeamAdd)
-	r.Get("/team/get", h.TeamGet)
-
-	r.Post("/users/setIsActive", h.UsersSetIsActive)
-	r.Get("/users/getReview", h.UsersGetReview)
-
-	r.Post("/pullRequest/create", h.PRCreate)
-	r.Post("/pullRequest/merge", h.PRMerge)
-	r.Post("/pullRequest/reassign", h.PRReassign)
-
-	return httptest.NewServer(r)
+	return httptest.NewServer(api.NewHandler(db, opts...).Routes())
 }
 
 func postJSON(t *testing.T, url string, body any) *http.Response {
+	t.Helper()
+	return postJSONAs(t, url, "", body)
+}
+
+// postJSONAs — postJSON с Bearer-токеном (пустой — без Authorization)
+func postJSONAs(t *testing.T, url, token string, body any) *http.Response {
 	t.Helper()
 	var rdr io.Reader
 	if body != nil {
@@ -78,6 +75,9 @@ func postJSON(t *testing.T, url string, body any) *http.Response {
 	}
 	req, _ := http.NewRequest(http.MethodPost, url, rdr)
 	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		t.Fatalf("POST %s: %v", url, err)
@@ -213,3 +213,1458 @@ func TestPR_Create_Merge_Reassign(t *testing.T) {
 		t.Fatalf("reassign: replaced_by not in new assigned list")
 	}
 }
+
+func TestReadyz(t *testing.T) {
+	db := mustNewDB(t)
+	srv := httptest.NewServer(api.NewHandler(db).Routes())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/readyz")
+	if err != nil {
+		t.Fatalf("GET /readyz: %v", err)
+	}
+	defer closeResp(t, resp)
+	var out api.Readiness
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	if resp.StatusCode != http.StatusOK || out.Status != "ready" {
+		t.Fatalf("readyz status=%d body=%+v", resp.StatusCode, out)
+	}
+	for _, dep := range []string{"database", "schema"} {
+		if out.Checks[dep].Status != "ok" {
+			t.Fatalf("%s check: %+v", dep, out.Checks[dep])
+		}
+	}
+}
+
+func TestTeamAdd_TokenScope(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	tokens, err := auth.ParseStaticTokens("adm-secret=admin,pay-secret=writer:payments,read-secret=reader")
+	if err != nil {
+		t.Fatalf("tokens: %v", err)
+	}
+	srv := mustNewServer(t, db, api.WithAuthenticator(tokens))
+	defer srv.Close()
+
+	addTeam := func(token, team string, members ...string) int {
+		var ms []map[string]any
+		for _, id := range members {
+			ms = append(ms, map[string]any{"user_id": id, "username": id, "is_active": true})
+		}
+		resp := postJSONAs(t, srv.URL+"/team/add", token, map[string]any{"team_name": team, "members": ms})
+		closeResp(t, resp)
+		return resp.StatusCode
+	}
+
+	if code := addTeam("", "backend", "u1"); code != http.StatusUnauthorized {
+		t.Fatalf("no token: status=%d (want 401)", code)
+	}
+	if code := addTeam("adm-secret", "backend", "u1", "u2"); code != http.StatusCreated {
+		t.Fatalf("admin: status=%d", code)
+	}
+	if code := addTeam("read-secret", "payments", "p1"); code != http.StatusForbidden {
+		t.Fatalf("reader: status=%d (want 403)", code)
+	}
+	if code := addTeam("pay-secret", "mobile", "m1"); code != http.StatusForbidden {
+		t.Fatalf("other team: status=%d (want 403)", code)
+	}
+	// u1 из backend в payments не переводится, команда не создаётся
+	if code := addTeam("pay-secret", "payments", "p1", "u1"); code != http.StatusForbidden {
+		t.Fatalf("foreign member: status=%d (want 403)", code)
+	}
+	var u1 model.UserDB
+	if err := db.First(&u1, "user_id = ?", "u1").Error; err != nil || u1.TeamName != "backend" || !u1.IsActive {
+		t.Fatalf("u1 changed: %+v err=%v", u1, err)
+	}
+	var teams int64
+	db.Model(&model.TeamDB{}).Where("team_name = ?", "payments").Count(&teams)
+	if teams != 0 {
+		t.Fatalf("payments created despite 403")
+	}
+	if code := addTeam("pay-secret", "payments", "p1"); code != http.StatusCreated {
+		t.Fatalf("own team: status=%d", code)
+	}
+	// админ может перевести пользователя из любой команды
+	if code := addTeam("adm-secret", "mobile", "u2"); code != http.StatusCreated {
+		t.Fatalf("admin move: status=%d", code)
+	}
+}
+
+func TestTeamSync_DeactivatesAndReassigns(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	team := map[string]any{
+		"team_name": "backend",
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+			{"user_id": "u3", "username": "Carol", "is_active": true},
+			{"user_id": "u4", "username": "Dave", "is_active": true},
+		},
+	}
+	resp := postJSON(t, srv.URL+"/team/add", team)
+	closeResp(t, resp)
+	resp = postJSON(t, srv.URL+"/pullRequest/create",
+		map[string]any{"pull_request_id": "pr-s1", "pull_request_name": "S", "author_id": "u1"})
+	var created struct {
+		PR struct {
+			Assigned []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	closeResp(t, resp)
+	if len(created.PR.Assigned) != 2 {
+		t.Fatalf("want 2 reviewers, got %v", created.PR.Assigned)
+	}
+	gone := created.PR.Assigned[0]
+
+	// ростер без одного из ревьюверов + новая команда; is_active не указан — участник активен
+	var members []map[string]any
+	for _, id := range []string{"u1", "u2", "u3", "u4"} {
+		if id != gone {
+			members = append(members, map[string]any{"user_id": id, "username": id})
+		}
+	}
+	backend := map[string]any{"team_name": "backend", "members": members}
+	roster := map[string]any{"teams": []map[string]any{
+		backend,
+		{"team_name": "payments", "min_senior_reviewers": 1, "max_open_reviews": 3,
+			"members": []map[string]any{{"user_id": "u9", "username": "Zoe"}}},
+	}}
+
+	type syncResp struct {
+		DryRun bool           `json:"dry_run"`
+		Diff   api.RosterDiff `json:"diff"`
+	}
+	sync := func(dryRun bool) syncResp {
+		roster["dry_run"] = dryRun
+		resp := postJSON(t, srv.URL+"/team/sync", roster)
+		defer closeResp(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			t.Fatalf("team/sync status=%d body=%s", resp.StatusCode, string(b))
+		}
+		var out syncResp
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return out
+	}
+
+	// dry-run: diff есть, но в БД ничего не меняется
+	dry := sync(true)
+	if len(dry.Diff.UsersDeactivated) != 1 || dry.Diff.UsersDeactivated[0] != gone || len(dry.Diff.Reassignments) != 1 {
+		t.Fatalf("dry-run diff: %+v", dry.Diff)
+	}
+	var teams int64
+	db.Table("teams").Where("team_name = 'payments'").Count(&teams)
+	if teams != 0 {
+		t.Fatalf("dry-run created a team")
+	}
+
+	wet := sync(false)
+	if len(wet.Diff.TeamsCreated) != 1 || wet.Diff.TeamsCreated[0] != "payments" {
+		t.Fatalf("teams_created: %+v", wet.Diff)
+	}
+	if len(wet.Diff.Reassignments) != 1 {
+		t.Fatalf("reassignments: %+v", wet.Diff.Reassignments)
+	}
+	if len(wet.Diff.UsersDeactivated) != 1 || len(wet.Diff.UsersActivated) != 0 {
+		t.Fatalf("only %s must be deactivated: %+v", gone, wet.Diff)
+	}
+	var payments model.TeamDB
+	if err := db.First(&payments, "team_name = ?", "payments").Error; err != nil ||
+		payments.MinSeniorReviewers != 1 || payments.MaxOpenReviews == nil || *payments.MaxOpenReviews != 3 {
+		t.Fatalf("payments team settings not applied: %+v err=%v", payments, err)
+	}
+	ra := wet.Diff.Reassignments[0]
+	if ra.OldUserID != gone || ra.NewUserID == "" || ra.NewUserID == gone || ra.NewUserID == "u1" {
+		t.Fatalf("bad reassignment: %+v", ra)
+	}
+
+	// повторная синхронизация ничего не меняет
+	again := sync(false)
+	if len(again.Diff.UsersDeactivated) != 0 || len(again.Diff.Reassignments) != 0 || len(again.Diff.TeamsCreated) != 0 ||
+		len(again.Diff.TeamsUpdated) != 0 {
+		t.Fatalf("sync is not idempotent: %+v", again.Diff)
+	}
+
+	// лимит существующей команды меняется ростером
+	backend["max_open_reviews"] = 5
+	if upd := sync(false); len(upd.Diff.TeamsUpdated) != 1 || upd.Diff.TeamsUpdated[0] != "backend" {
+		t.Fatalf("teams_updated: %+v", upd.Diff)
+	}
+}
+
+func TestPRList_FiltersAndPagination(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	for _, team := range []map[string]any{
+		{"team_name": "backend", "members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+		}},
+		{"team_name": "frontend", "members": []map[string]any{
+			{"user_id": "u5", "username": "Eve", "is_active": true},
+		}},
+	} {
+		closeResp(t, postJSON(t, srv.URL+"/team/add", team))
+	}
+	for _, pr := range []struct{ id, author string }{
+		{"pr-1", "u1"}, {"pr-2", "u1"}, {"pr-3", "u1"}, {"pr-4", "u5"},
+	} {
+		closeResp(t, postJSON(t, srv.URL+"/pullRequest/create",
+			map[string]any{"pull_request_id": pr.id, "pull_request_name": pr.id, "author_id": pr.author}))
+	}
+	closeResp(t, postJSON(t, srv.URL+"/pullRequest/merge", map[string]any{"pull_request_id": "pr-2"}))
+
+	type page struct {
+		PRs  []api.PullRequest `json:"pull_requests"`
+		Next string            `json:"next_cursor"`
+	}
+	list := func(query string) page {
+		resp, err := http.Get(srv.URL + "/pullRequest/list?" + query)
+		if err != nil {
+			t.Fatalf("GET list: %v", err)
+		}
+		defer closeResp(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			t.Fatalf("list status=%d body=%s", resp.StatusCode, string(b))
+		}
+		var p page
+		_ = json.NewDecoder(resp.Body).Decode(&p)
+		return p
+	}
+	ids := func(p page) []string {
+		out := make([]string, 0, len(p.PRs))
+		for _, pr := range p.PRs {
+			out = append(out, pr.PullRequestID)
+		}
+		return out
+	}
+
+	// открытые PR команды backend
+	got := ids(list("status=OPEN&team_name=backend&sort=pull_request_id"))
+	if strings.Join(got, ",") != "pr-1,pr-3" {
+		t.Fatalf("open backend PRs: %v", got)
+	}
+	// статус без учёта регистра
+	if got = ids(list("status=merged")); strings.Join(got, ",") != "pr-2" {
+		t.Fatalf("merged PRs: %v", got)
+	}
+	// ревьювер u2 назначен на все PR u1
+	got = ids(list("reviewer_id=u2&sort=-pull_request_id"))
+	if strings.Join(got, ",") != "pr-3,pr-2,pr-1" {
+		t.Fatalf("reviewer u2 PRs: %v", got)
+	}
+
+	// постранично по 3 — все 4 PR без повторов, на последней странице нет курсора
+	var all []string
+	p := list("limit=3")
+	all = append(all, ids(p)...)
+	if p.Next == "" {
+		t.Fatalf("first page has no next_cursor")
+	}
+	p = list("limit=3&cursor=" + p.Next)
+	all = append(all, ids(p)...)
+	if p.Next != "" || len(all) != 4 {
+		t.Fatalf("pagination: %v next=%q", all, p.Next)
+	}
+	seen := map[string]bool{}
+	for _, id := range all {
+		if seen[id] {
+			t.Fatalf("duplicate %s across pages: %v", id, all)
+		}
+		seen[id] = true
+	}
+}
+
+func TestPRGet_DetailsHistoryAndETag(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	closeResp(t, postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name": "backend",
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+			{"user_id": "u3", "username": "Carol", "is_active": true},
+			{"user_id": "u4", "username": "Dave", "is_active": true},
+		},
+	}))
+	resp := postJSON(t, srv.URL+"/pullRequest/create",
+		map[string]any{"pull_request_id": "pr-g1", "pull_request_name": "G", "author_id": "u1"})
+	var created struct {
+		PR api.PullRequest `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	closeResp(t, resp)
+	closeResp(t, postJSON(t, srv.URL+"/pullRequest/reassign",
+		map[string]any{"pull_request_id": "pr-g1", "old_user_id": created.PR.Assigned[0]}))
+	closeResp(t, postJSON(t, srv.URL+"/pullRequest/merge", map[string]any{"pull_request_id": "pr-g1"}))
+	closeResp(t, postJSON(t, srv.URL+"/pullRequest/merge", map[string]any{"pull_request_id": "pr-g1"}))
+
+	get := func(etag string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/pullRequest/get?pull_request_id=pr-g1", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET pr: %v", err)
+		}
+		return resp
+	}
+
+	resp = get("")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("get status=%d", resp.StatusCode)
+	}
+	etag := resp.Header.Get("ETag")
+	var got struct {
+		PR api.PullRequestDetail `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&got)
+	closeResp(t, resp)
+
+	if got.PR.Status != "MERGED" || len(got.PR.Reviewers) != 2 || got.PR.Reviewers[0].TeamName != "backend" {
+		t.Fatalf("bad PR: %+v", got.PR)
+	}
+	var kinds []string
+	for _, ev := range got.PR.History.Events {
+		kinds = append(kinds, ev.Kind)
+	}
+	// повторный merge не пишет второе событие
+	if strings.Join(kinds, ",") != "CREATED,ASSIGNED,ASSIGNED,REASSIGNED,MERGED" || got.PR.History.Reassignments != 1 {
+		t.Fatalf("history: %+v", got.PR.History)
+	}
+
+	// переназначение и merge — версия 3; тот же формат, что у изменяющих вызовов
+	if etag != `"v3"` {
+		t.Fatalf("ETag=%s (want \"v3\")", etag)
+	}
+	resp = get(etag)
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("If-None-Match: status=%d (want 304)", resp.StatusCode)
+	}
+
+	resp, _ = http.Get(srv.URL + "/pullRequest/get?pull_request_id=nope")
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing PR: status=%d (want 404)", resp.StatusCode)
+	}
+}
+
+func TestUsersGetReview_StatusAndPagination(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	// двое в команде: u2 — единственный кандидат на все PR u1
+	closeResp(t, postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name": "backend",
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+		},
+	}))
+	for _, id := range []string{"pr-r1", "pr-r2", "pr-r3"} {
+		closeResp(t, postJSON(t, srv.URL+"/pullRequest/create",
+			map[string]any{"pull_request_id": id, "pull_request_name": id, "author_id": "u1"}))
+	}
+	closeResp(t, postJSON(t, srv.URL+"/pullRequest/merge", map[string]any{"pull_request_id": "pr-r2"}))
+
+	type page struct {
+		PRs  []api.ReviewItem `json:"pull_requests"`
+		Next string           `json:"next_cursor"`
+	}
+	get := func(query string) page {
+		resp, err := http.Get(srv.URL + "/users/getReview?user_id=u2&" + query)
+		if err != nil {
+			t.Fatalf("GET getReview: %v", err)
+		}
+		defer closeResp(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			t.Fatalf("getReview status=%d body=%s", resp.StatusCode, string(b))
+		}
+		var p page
+		_ = json.NewDecoder(resp.Body).Decode(&p)
+		return p
+	}
+
+	// по умолчанию — только открытые, без age
+	p := get("")
+	if len(p.PRs) != 2 || p.Next != "" {
+		t.Fatalf("default: %+v", p)
+	}
+	for _, pr := range p.PRs {
+		if pr.Status != "OPEN" || pr.AgeSeconds != nil {
+			t.Fatalf("default item: %+v", pr)
+		}
+	}
+
+	// все статусы по одному на страницу
+	seen := map[string]string{}
+	cursor := ""
+	for i := 0; i < 5; i++ {
+		p = get("status=ALL&limit=1&include=age&cursor=" + cursor)
+		for _, pr := range p.PRs {
+			seen[pr.PullRequestID] = pr.Status
+			if pr.AssignedAt == nil || pr.AgeSeconds == nil || *pr.AgeSeconds < 0 {
+				t.Fatalf("age not filled: %+v", pr)
+			}
+		}
+		if p.Next == "" {
+			break
+		}
+		cursor = p.Next
+	}
+	if len(seen) != 3 || seen["pr-r2"] != "MERGED" || seen["pr-r1"] != "OPEN" {
+		t.Fatalf("all pages: %v", seen)
+	}
+}
+
+func TestStats_WindowAndTeam(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	for _, team := range []map[string]any{
+		{"team_name": "backend", "members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+			{"user_id": "u3", "username": "Carol", "is_active": true},
+		}},
+		// одиночка: его PR остаются без ревьюверов
+		{"team_name": "solo", "members": []map[string]any{
+			{"user_id": "u9", "username": "Zoe", "is_active": true},
+		}},
+	} {
+		closeResp(t, postJSON(t, srv.URL+"/team/add", team))
+	}
+	for _, pr := range []struct{ id, author string }{{"pr-t1", "u1"}, {"pr-t2", "u1"}, {"pr-t3", "u9"}} {
+		closeResp(t, postJSON(t, srv.URL+"/pullRequest/create",
+			map[string]any{"pull_request_id": pr.id, "pull_request_name": pr.id, "author_id": pr.author}))
+	}
+	closeResp(t, postJSON(t, srv.URL+"/pullRequest/merge", map[string]any{"pull_request_id": "pr-t1"}))
+
+	get := func(path string, out any) {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer closeResp(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			t.Fatalf("%s status=%d body=%s", path, resp.StatusCode, string(b))
+		}
+		_ = json.NewDecoder(resp.Body).Decode(out)
+	}
+
+	var backend api.StatsSummary
+	get("/stats/summary?team_name=backend", &backend)
+	if backend.PRsCreated != 2 || backend.PRsMerged != 1 || backend.PRsOpen != 1 ||
+		backend.Assignments != 4 || backend.NoCandidate != 0 || backend.MedianTimeToMergeSeconds == nil ||
+		backend.MedianTimeToFirstReviewSeconds == nil {
+		t.Fatalf("backend summary: %+v", backend)
+	}
+	var solo api.StatsSummary
+	get("/stats/summary?team_name=solo", &solo)
+	if solo.PRsCreated != 1 || solo.NoCandidate != 2 || solo.NoCandidateRate != 1 {
+		t.Fatalf("solo summary: %+v", solo)
+	}
+
+	var reviewers struct {
+		Items []api.ReviewerStats `json:"items"`
+	}
+	get("/stats/reviewers?team_name=backend", &reviewers)
+	load := map[string]api.ReviewerStats{}
+	for _, it := range reviewers.Items {
+		load[it.UserID] = it
+	}
+	if len(load) != 3 || load["u2"].Assigned != 2 || load["u3"].Assigned != 2 || load["u2"].OpenLoad != 1 {
+		t.Fatalf("reviewers: %+v", reviewers.Items)
+	}
+
+	// окно в прошлом — пусто
+	var past api.StatsSummary
+	get("/stats/summary?from=2020-01-01T00:00:00Z&to=2020-02-01T00:00:00Z", &past)
+	if past.PRsCreated != 0 || past.Assignments != 0 {
+		t.Fatalf("past window: %+v", past)
+	}
+}
+
+func TestStatsFairness_JSONAndCSV(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	closeResp(t, postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name": "backend",
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+			{"user_id": "u3", "username": "Carol", "is_active": true},
+		},
+	}))
+	// u3 ушёл в отпуск — все ревью достаются u2
+	closeResp(t, postJSON(t, srv.URL+"/users/setIsActive", map[string]any{"user_id": "u3", "is_active": false}))
+	for _, id := range []string{"pr-f1", "pr-f2", "pr-f3"} {
+		closeResp(t, postJSON(t, srv.URL+"/pullRequest/create",
+			map[string]any{"pull_request_id": id, "pull_request_name": id, "author_id": "u1"}))
+	}
+
+	var activity int64
+	db.Table("user_activity").Where("user_id = 'u3'").Count(&activity)
+	if activity != 2 {
+		t.Fatalf("u3 activity log: %d rows (want create + deactivate)", activity)
+	}
+
+	resp, err := http.Get(srv.URL + "/stats/fairness?team_name=backend")
+	if err != nil {
+		t.Fatalf("GET fairness: %v", err)
+	}
+	var rep api.FairnessReport
+	_ = json.NewDecoder(resp.Body).Decode(&rep)
+	closeResp(t, resp)
+	if rep.TotalAssignments != 3 || len(rep.Members) != 3 {
+		t.Fatalf("report: %+v", rep)
+	}
+	if top := rep.Members[0]; top.UserID != "u2" || top.Assignments != 3 || top.Outlier != "over" {
+		t.Fatalf("u2 should be the overloaded one: %+v", rep.Members)
+	}
+
+	resp, err = http.Get(srv.URL + "/stats/fairness?team_name=backend&format=csv")
+	if err != nil {
+		t.Fatalf("GET fairness csv: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	closeResp(t, resp)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/csv") || len(lines) != 4 ||
+		!strings.HasPrefix(lines[0], "team_name,from,to,gini,user_id") {
+		t.Fatalf("csv: %s", body)
+	}
+}
+
+func TestExport_CSVAndNDJSON(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	closeResp(t, postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name": "backend",
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+			{"user_id": "u3", "username": "Carol", "is_active": true},
+		},
+	}))
+	for _, id := range []string{"pr-e1", "pr-e2"} {
+		closeResp(t, postJSON(t, srv.URL+"/pullRequest/create",
+			map[string]any{"pull_request_id": id, "pull_request_name": id, "author_id": "u1"}))
+	}
+	closeResp(t, postJSON(t, srv.URL+"/pullRequest/merge", map[string]any{"pull_request_id": "pr-e1"}))
+
+	get := func(path string) (string, string) {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer closeResp(t, resp)
+		b, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s status=%d body=%s", path, resp.StatusCode, string(b))
+		}
+		return resp.Header.Get("Content-Type"), string(b)
+	}
+
+	ctype, body := get("/export/pullRequests")
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if ctype != "application/x-ndjson" || len(lines) != 2 {
+		t.Fatalf("ndjson %q: %s", ctype, body)
+	}
+	var first api.ExportPR
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil ||
+		first.PullRequestID != "pr-e1" || first.MergedAt == nil || len(first.Reviewers) != 2 || first.AuthorTeam != "backend" {
+		t.Fatalf("first PR: %v %+v", err, first)
+	}
+
+	_, body = get("/export/assignments?format=csv")
+	lines = strings.Split(strings.TrimSpace(body), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[0], "pull_request_id,position,reviewer_id") {
+		t.Fatalf("assignments csv: %s", body)
+	}
+
+	// окно в будущем — только заголовок
+	_, body = get("/export/events?format=csv&from=2100-01-01T00:00:00Z")
+	if strings.TrimSpace(body) != "id,pull_request_id,kind,old_user_id,new_user_id,at" {
+		t.Fatalf("events csv: %s", body)
+	}
+}
+
+func TestPRImport_HistoryAndRowErrors(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	closeResp(t, postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name": "backend",
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": false},
+		},
+	}))
+	body := strings.Join([]string{
+		`{"pull_request_id":"old-1","pull_request_name":"A","author_id":"u1","created_at":"2024-03-01T10:00:00Z","merged_at":"2024-03-02T10:00:00Z","reviewers":["u2"]}`,
+		`{"pull_request_id":"old-2","pull_request_name":"B","author_id":"ghost","created_at":"2024-03-01T10:00:00Z"}`,
+		`{"pull_request_id":"old-1","pull_request_name":"A again","author_id":"u1","created_at":"2024-03-01T10:00:00Z"}`,
+		`{"pull_request_id":"old-3","pull_request_name":"C","author_id":"u1","created_at":"2024-03-05T10:00:00Z"}`,
+	}, "\n")
+	// пачки по 2: ошибки во второй пачке не откатывают первую и соседнюю строку
+	resp, err := http.Post(srv.URL+"/pullRequest/import?batch_size=2", "application/x-ndjson", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	var res api.ImportResult
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+	closeResp(t, resp)
+	if res.Imported != 2 || res.Failed != 2 ||
+		res.Errors[0].Line != 2 || res.Errors[0].Code != "NOT_FOUND" ||
+		res.Errors[1].Line != 3 || res.Errors[1].Code != "PR_EXISTS" {
+		t.Fatalf("import result: %+v", res)
+	}
+
+	resp, _ = http.Get(srv.URL + "/pullRequest/get?pull_request_id=old-1")
+	var got struct {
+		PR api.PullRequestDetail `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&got)
+	closeResp(t, resp)
+	if got.PR.Status != "MERGED" || got.PR.CreatedAt == nil || got.PR.CreatedAt.Year() != 2024 ||
+		strings.Join(got.PR.Assigned, ",") != "u2" || len(got.PR.History.Events) != 3 ||
+		got.PR.History.Events[2].At.Day() != 2 {
+		t.Fatalf("imported PR: %+v", got.PR)
+	}
+}
+
+func TestPRCreateBatch_BalancedAndPerItemErrors(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	closeResp(t, postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name": "backend",
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+			{"user_id": "u3", "username": "Carol", "is_active": true},
+			{"user_id": "u4", "username": "Dave", "is_active": true},
+		},
+	}))
+	closeResp(t, postJSON(t, srv.URL+"/pullRequest/create", map[string]any{
+		"pull_request_id": "pr-old", "pull_request_name": "old", "author_id": "u1",
+	}))
+	_ = db.Exec("UPDATE pull_requests SET status = 'MERGED' WHERE pull_request_id = 'pr-old'")
+
+	var prs []map[string]any
+	for i := range 6 {
+		prs = append(prs, map[string]any{"pull_request_id": fmt.Sprintf("stack-%d", i), "pull_request_name": "stack", "author_id": "u1"})
+	}
+	prs = append(prs,
+		map[string]any{"pull_request_id": "stack-0", "pull_request_name": "dup", "author_id": "u1"},
+		map[string]any{"pull_request_id": "pr-old", "pull_request_name": "dup", "author_id": "u1"},
+		map[string]any{"pull_request_id": "ghost-1", "pull_request_name": "x", "author_id": "ghost"},
+	)
+	resp := postJSON(t, srv.URL+"/pullRequest/createBatch", map[string]any{"pull_requests": prs})
+	var res api.BatchCreateResult
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusOK || res.Created != 6 || res.Failed != 3 || len(res.Results) != 9 {
+		t.Fatalf("batch: status=%d %+v", resp.StatusCode, res)
+	}
+	for i, code := range map[int]string{6: "PR_EXISTS", 7: "PR_EXISTS", 8: "NOT_FOUND"} {
+		if res.Results[i].Code != code || res.Results[i].PR != nil {
+			t.Fatalf("result %d: %+v", i, res.Results[i])
+		}
+	}
+	// 6 PR x 2 слота на u2..u4 — ровно по 4 назначения
+	load := map[string]int{}
+	for _, it := range res.Results[:6] {
+		if it.PR == nil || len(it.PR.Assigned) != 2 {
+			t.Fatalf("created item: %+v", it)
+		}
+		for _, rv := range it.PR.Assigned {
+			load[rv]++
+		}
+	}
+	if load["u2"] != 4 || load["u3"] != 4 || load["u4"] != 4 {
+		t.Fatalf("unbalanced: %v", load)
+	}
+
+	var events int64
+	db.Model(&model.PREventDB{}).Where("pr_id LIKE 'stack-%' AND kind = ?", model.EventAssigned).Count(&events)
+	if events != 12 {
+		t.Fatalf("ASSIGNED events=%d (want 12)", events)
+	}
+}
+
+func TestIdempotencyKey_ReplaysCreate(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	closeResp(t, postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name": "backend",
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+			{"user_id": "u3", "username": "Carol", "is_active": true},
+			{"user_id": "u4", "username": "Dave", "is_active": true},
+		},
+	}))
+	send := func(key, body string) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/pullRequest/create", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		closeResp(t, resp)
+		return resp, string(b)
+	}
+	body := `{"pull_request_id":"pr-1","pull_request_name":"A","author_id":"u1"}`
+
+	first, firstBody := send("k-1", body)
+	again, againBody := send("k-1", body)
+	if first.StatusCode != http.StatusCreated || again.StatusCode != http.StatusCreated || againBody != firstBody {
+		t.Fatalf("replay: %d %s / %d %s", first.StatusCode, firstBody, again.StatusCode, againBody)
+	}
+	if first.Header.Get("Idempotent-Replayed") != "" || again.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("Idempotent-Replayed header: %q / %q",
+			first.Header.Get("Idempotent-Replayed"), again.Header.Get("Idempotent-Replayed"))
+	}
+	if first.Header.Get("ETag") != `"v1"` || again.Header.Get("ETag") != first.Header.Get("ETag") {
+		t.Fatalf("ETag: %q / %q", first.Header.Get("ETag"), again.Header.Get("ETag"))
+	}
+
+	// тот же ключ с другим телом — ошибка клиента; без ключа повтор — обычный 409
+	if resp, _ := send("k-1", `{"pull_request_id":"pr-2","pull_request_name":"B","author_id":"u1"}`); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key: status=%d", resp.StatusCode)
+	}
+	if resp, _ := send("k-2", body); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("new key, existing PR: status=%d", resp.StatusCode)
+	}
+
+	var events int64
+	db.Model(&model.PREventDB{}).Where("pr_id = 'pr-1' AND kind = ?", model.EventCreated).Count(&events)
+	if events != 1 {
+		t.Fatalf("CREATED events=%d (want 1)", events)
+	}
+}
+
+func TestPRVersion_IfMatchAndConcurrentReassign(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	closeResp(t, postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name": "backend",
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+			{"user_id": "u3", "username": "Carol", "is_active": true},
+			{"user_id": "u4", "username": "Dave", "is_active": true},
+			{"user_id": "u5", "username": "Eve", "is_active": true},
+		},
+	}))
+	resp := postJSON(t, srv.URL+"/pullRequest/create",
+		map[string]any{"pull_request_id": "pr-v1", "pull_request_name": "V", "author_id": "u1"})
+	var created struct {
+		PR api.PullRequest `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	closeResp(t, resp)
+	if created.PR.Version != 1 || resp.Header.Get("ETag") != `"v1"` {
+		t.Fatalf("created: version=%d ETag=%s", created.PR.Version, resp.Header.Get("ETag"))
+	}
+
+	post := func(path, ifMatch string, body map[string]any) *http.Response {
+		b, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+path, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST %s: %v", path, err)
+		}
+		closeResp(t, resp)
+		return resp
+	}
+
+	// ETag из /pullRequest/get принимается в If-Match
+	resp, _ = http.Get(srv.URL + "/pullRequest/get?pull_request_id=pr-v1")
+	closeResp(t, resp)
+	getETag := resp.Header.Get("ETag")
+	old := created.PR.Assigned[0]
+	if resp := post("/pullRequest/reassign", getETag, map[string]any{"pull_request_id": "pr-v1", "old_user_id": old}); resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != `"v2"` {
+		t.Fatalf("reassign with fresh ETag: status=%d ETag=%s", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+	// тот же ETag устарел
+	second := created.PR.Assigned[1]
+	if resp := post("/pullRequest/reassign", getETag, map[string]any{"pull_request_id": "pr-v1", "old_user_id": second}); resp.StatusCode != http.StatusPreconditionFailed || resp.Header.Get("ETag") != `"v2"` {
+		t.Fatalf("stale If-Match: status=%d ETag=%s", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+
+	// параллельные переназначения одного ревьювера: ровно одно проходит, остальные видят, что он уже снят
+	const n = 8
+	codes := make(chan int, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- post("/pullRequest/reassign", "", map[string]any{"pull_request_id": "pr-v1", "old_user_id": second}).StatusCode
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	ok := 0
+	for c := range codes {
+		switch c {
+		case http.StatusOK:
+			ok++
+		case http.StatusConflict:
+		default:
+			t.Fatalf("concurrent reassign: status=%d", c)
+		}
+	}
+	var reassigned int64
+	db.Model(&model.PREventDB{}).Where("pr_id = 'pr-v1' AND kind = ?", model.EventReassigned).Count(&reassigned)
+	if ok != 1 || reassigned != 2 {
+		t.Fatalf("concurrent reassign: ok=%d REASSIGNED events=%d (want 1 and 2)", ok, reassigned)
+	}
+
+	if resp := post("/pullRequest/merge", `"v1"`, map[string]any{"pull_request_id": "pr-v1"}); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("merge with stale If-Match: status=%d", resp.StatusCode)
+	}
+	// слабый ETag не годится для If-Match
+	if resp := post("/pullRequest/merge", `W/"v3"`, map[string]any{"pull_request_id": "pr-v1"}); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("merge with weak If-Match: status=%d", resp.StatusCode)
+	}
+	if resp := post("/pullRequest/merge", `"v3"`, map[string]any{"pull_request_id": "pr-v1"}); resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != `"v4"` {
+		t.Fatalf("merge: status=%d ETag=%s", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+	// повтор merge со старым ETag — идемпотентный 200
+	if resp := post("/pullRequest/merge", `"v3"`, map[string]any{"pull_request_id": "pr-v1"}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("repeated merge: status=%d", resp.StatusCode)
+	}
+}
+
+func TestPRDecline_ReassignsAndRecordsReason(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	closeResp(t, postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name": "backend",
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+			{"user_id": "u3", "username": "Carol", "is_active": true},
+			{"user_id": "u4", "username": "Dave", "is_active": true},
+		},
+	}))
+	resp := postJSON(t, srv.URL+"/pullRequest/create",
+		map[string]any{"pull_request_id": "pr-d1", "pull_request_name": "D", "author_id": "u1"})
+	var created struct {
+		PR api.PullRequest `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	closeResp(t, resp)
+	first, second := created.PR.Assigned[0], created.PR.Assigned[1]
+
+	decline := func(userID, reason string) (int, api.DeclineResult) {
+		resp := postJSON(t, srv.URL+"/pullRequest/decline",
+			map[string]any{"pull_request_id": "pr-d1", "user_id": userID, "reason": reason})
+		var out api.DeclineResult
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		closeResp(t, resp)
+		return resp.StatusCode, out
+	}
+
+	// замена — единственный свободный участник команды
+	code, res := decline(first, "BUSY")
+	if code != http.StatusOK || res.ReplacedBy == "" || res.ReplacedBy == first || res.ReplacedBy == second ||
+		res.Reason != "BUSY" || res.PR.Version != 2 {
+		t.Fatalf("decline: %d %+v", code, res)
+	}
+	// замены больше нет — слот снимается
+	code, res = decline(second, "CONFLICT_OF_INTEREST")
+	if code != http.StatusOK || res.ReplacedBy != "" || len(res.PR.Assigned) != 1 {
+		t.Fatalf("decline without candidate: %d %+v", code, res)
+	}
+	if code, _ = decline(second, "BUSY"); code != http.StatusConflict {
+		t.Fatalf("repeated decline: status=%d (want 409)", code)
+	}
+
+	resp, _ = http.Get(srv.URL + "/pullRequest/get?pull_request_id=pr-d1")
+	var got struct {
+		PR api.PullRequestDetail `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&got)
+	closeResp(t, resp)
+	evs := got.PR.History.Events
+	if len(evs) != 6 || evs[3].Kind != model.EventReassigned || evs[3].Reason != "BUSY" ||
+		evs[4].Kind != model.EventUnassigned || evs[4].Reason != "CONFLICT_OF_INTEREST" ||
+		evs[5].Kind != model.EventNoCandidate || !evs[5].At.Equal(evs[4].At) {
+		t.Fatalf("history: %+v", evs)
+	}
+	// ручное снятие (как в setReviewers) — UNASSIGNED без пары NO_CANDIDATE, в reassigned_out не входит
+	if err := db.Create(&model.PREventDB{PRID: "pr-d1", Kind: model.EventUnassigned, OldUserID: &first, CreatedAt: time.Now()}).Error; err != nil {
+		t.Fatalf("insert event: %v", err)
+	}
+
+	resp, _ = http.Get(srv.URL + "/stats/summary?team_name=backend")
+	var sum api.StatsSummary
+	_ = json.NewDecoder(resp.Body).Decode(&sum)
+	closeResp(t, resp)
+	if sum.DeclinesByReason["BUSY"] != 1 || sum.DeclinesByReason["CONFLICT_OF_INTEREST"] != 1 {
+		t.Fatalf("declines_by_reason: %+v", sum.DeclinesByReason)
+	}
+	if sum.NoCandidate != 1 || sum.MedianTimeToFirstReviewSeconds == nil {
+		t.Fatalf("summary: %+v", sum)
+	}
+
+	resp, _ = http.Get(srv.URL + "/stats/reviewers?team_name=backend")
+	var reviewers struct {
+		Items []api.ReviewerStats `json:"items"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&reviewers)
+	closeResp(t, resp)
+	for _, it := range reviewers.Items {
+		if (it.UserID == first || it.UserID == second) && (it.ReassignedOut != 1 || it.Declined != 1) {
+			t.Fatalf("reviewer %s: %+v", it.UserID, it)
+		}
+	}
+}
+
+func TestPinnedReviewers_CreateSetAndSync(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	closeResp(t, postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name": "backend",
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+			{"user_id": "u3", "username": "Carol", "is_active": true},
+		},
+	}))
+	closeResp(t, postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name": "frontend",
+		"members": []map[string]any{
+			{"user_id": "f1", "username": "Frank", "is_active": true},
+			{"user_id": "f2", "username": "Fiona", "is_active": false},
+		},
+	}))
+
+	create := func(id string, requested []string) (int, api.PullRequest) {
+		resp := postJSON(t, srv.URL+"/pullRequest/create", map[string]any{
+			"pull_request_id": id, "pull_request_name": id, "author_id": "u1", "requested_reviewers": requested})
+		var out struct {
+			PR api.PullRequest `json:"pr"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		closeResp(t, resp)
+		return resp.StatusCode, out.PR
+	}
+	for _, tc := range []struct {
+		requested []string
+		want      int
+	}{
+		{[]string{"u1"}, http.StatusBadRequest},
+		{[]string{"u2", "u2"}, http.StatusBadRequest},
+		{[]string{"u2", "u3", "f1"}, http.StatusBadRequest},
+		{[]string{"ghost"}, http.StatusNotFound},
+		{[]string{"f2"}, http.StatusConflict},
+	} {
+		if code, _ := create("pr-bad", tc.requested); code != tc.want {
+			t.Fatalf("requested %v: status=%d (want %d)", tc.requested, code, tc.want)
+		}
+	}
+
+	// запрошенный ревьювер из другой команды — первый слот, второй выбирается автоматически
+	code, pr := create("pr-p1", []string{"f1"})
+	if code != http.StatusCreated || len(pr.Assigned) != 2 || pr.Assigned[0] != "f1" ||
+		len(pr.Pinned) != 1 || pr.Pinned[0] != "f1" || pr.Assigned[1] == "u1" {
+		t.Fatalf("create: %d %+v", code, pr)
+	}
+	auto := pr.Assigned[1]
+
+	// ростер без f1: f1 деактивируется, но закреплённый слот не переназначается
+	resp := postJSON(t, srv.URL+"/team/sync", map[string]any{"teams": []map[string]any{
+		{"team_name": "backend", "members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+			{"user_id": "u3", "username": "Carol", "is_active": true},
+		}},
+		{"team_name": "frontend", "members": []map[string]any{
+			{"user_id": "f2", "username": "Fiona", "is_active": false},
+		}},
+	}})
+	var synced struct {
+		Diff api.RosterDiff `json:"diff"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&synced)
+	closeResp(t, resp)
+	if len(synced.Diff.UsersDeactivated) != 1 || len(synced.Diff.Reassignments) != 0 {
+		t.Fatalf("sync diff: %+v", synced.Diff)
+	}
+
+	setReviewers := func(ids []string) (int, api.PullRequest) {
+		resp := postJSON(t, srv.URL+"/pullRequest/setReviewers",
+			map[string]any{"pull_request_id": "pr-p1", "reviewers": ids})
+		var out struct {
+			PR api.PullRequest `json:"pr"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		closeResp(t, resp)
+		return resp.StatusCode, out.PR
+	}
+	if code, _ := setReviewers([]string{"u1"}); code != http.StatusBadRequest {
+		t.Fatalf("author as reviewer: status=%d (want 400)", code)
+	}
+	// f1 снят, автоматически выбранный ревьювер сохраняется во втором слоте
+	other := "u2"
+	if auto == "u2" {
+		other = "u3"
+	}
+	code, pr = setReviewers([]string{other})
+	if code != http.StatusOK || len(pr.Assigned) != 2 || pr.Assigned[0] != other || pr.Assigned[1] != auto ||
+		len(pr.Pinned) != 1 || pr.Pinned[0] != other || pr.Version != 2 {
+		t.Fatalf("setReviewers: %d %+v", code, pr)
+	}
+
+	resp, _ = http.Get(srv.URL + "/pullRequest/get?pull_request_id=pr-p1")
+	var got struct {
+		PR api.PullRequestDetail `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&got)
+	closeResp(t, resp)
+	evs := got.PR.History.Events
+	last := evs[len(evs)-1]
+	if last.Kind != model.EventReassigned || last.OldUserID != "f1" || last.NewUserID != other {
+		t.Fatalf("history: %+v", evs)
+	}
+	// ревьювер, добавленный setReviewers в пустой слот (событие ASSIGNED), ревьюит с момента добавления
+	setActive := func(id string, active bool) {
+		closeResp(t, postJSON(t, srv.URL+"/users/setIsActive", map[string]any{"user_id": id, "is_active": active}))
+	}
+	setActive("u3", false)
+	if code, pr := create("pr-p2", nil); code != http.StatusCreated || len(pr.Assigned) != 1 || pr.Assigned[0] != "u2" {
+		t.Fatalf("create pr-p2: %d %+v", code, pr)
+	}
+	setActive("u3", true)
+	time.Sleep(10 * time.Millisecond)
+	resp = postJSON(t, srv.URL+"/pullRequest/setReviewers", map[string]any{"pull_request_id": "pr-p2", "reviewers": []string{"u3"}})
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("setReviewers pr-p2: status=%d", resp.StatusCode)
+	}
+	resp, _ = http.Get(srv.URL + "/users/getReview?user_id=u3&include=age")
+	var reviews struct {
+		PRs []api.ReviewItem `json:"pull_requests"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&reviews)
+	closeResp(t, resp)
+	resp, _ = http.Get(srv.URL + "/pullRequest/get?pull_request_id=pr-p2")
+	_ = json.NewDecoder(resp.Body).Decode(&got)
+	closeResp(t, resp)
+	found := false
+	for _, it := range reviews.PRs {
+		if it.PullRequestID != "pr-p2" {
+			continue
+		}
+		found = true
+		if it.AssignedAt == nil || got.PR.CreatedAt == nil || !it.AssignedAt.After(*got.PR.CreatedAt) {
+			t.Fatalf("u3 on pr-p2: assigned_at=%v created=%v", it.AssignedAt, got.PR.CreatedAt)
+		}
+	}
+	if !found {
+		t.Fatalf("pr-p2 not in u3 reviews: %+v", reviews.PRs)
+	}
+}
+
+func TestReviewerRules_ExcludeAndRequire(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	closeResp(t, postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name": "backend",
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "u2", "username": "Bob", "is_active": true},
+			{"user_id": "u3", "username": "Carol", "is_active": true},
+			{"user_id": "u4", "username": "Dave", "is_active": true},
+		},
+	}))
+	closeResp(t, postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name": "mentors",
+		"members": []map[string]any{
+			{"user_id": "s1", "username": "Sam", "is_active": true},
+			{"user_id": "s2", "username": "Sue", "is_active": true},
+		},
+	}))
+
+	addRule := func(rule map[string]any) (int, api.ReviewerRule) {
+		resp := postJSON(t, srv.URL+"/rules/add", rule)
+		var out struct {
+			Rule api.ReviewerRule `json:"rule"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		closeResp(t, resp)
+		return resp.StatusCode, out.Rule
+	}
+	code, excl := addRule(map[string]any{"kind": "EXCLUDE", "team_name": "backend", "author_id": "u1", "reviewer_id": "u2"})
+	if code != http.StatusCreated || excl.ID == 0 || excl.TeamName != "backend" {
+		t.Fatalf("add EXCLUDE: %d %+v", code, excl)
+	}
+	for _, id := range []string{"s1", "s2"} {
+		if code, _ := addRule(map[string]any{"kind": "REQUIRE", "author_id": "u1", "reviewer_id": id}); code != http.StatusCreated {
+			t.Fatalf("add REQUIRE %s: status=%d", id, code)
+		}
+	}
+	if code, _ := addRule(map[string]any{"kind": "REQUIRE", "author_id": "u1", "reviewer_id": "u2"}); code != http.StatusConflict {
+		t.Fatalf("second rule for pair: status=%d (want 409)", code)
+	}
+	if code, _ := addRule(map[string]any{"kind": "EXCLUDE", "team_name": "mentors", "author_id": "u1", "reviewer_id": "u3"}); code != http.StatusBadRequest {
+		t.Fatalf("author outside team_name: status=%d (want 400)", code)
+	}
+
+	// u2 исключён, первый слот — обязательный ментор, второй — из команды автора
+	isMentor := func(id string) bool { return id == "s1" || id == "s2" }
+	var last api.PullRequest
+	for i := 0; i < 6; i++ {
+		resp := postJSON(t, srv.URL+"/pullRequest/create", map[string]any{
+			"pull_request_id": fmt.Sprintf("pr-r%d", i), "pull_request_name": "R", "author_id": "u1"})
+		var out struct {
+			PR api.PullRequest `json:"pr"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		closeResp(t, resp)
+		got := out.PR.Assigned
+		if len(got) != 2 || !isMentor(got[0]) || got[1] == "u2" || isMentor(got[1]) {
+			t.Fatalf("pr-r%d reviewers: %v", i, got)
+		}
+		last = out.PR
+	}
+	resp := postJSON(t, srv.URL+"/pullRequest/create", map[string]any{
+		"pull_request_id": "pr-rx", "pull_request_name": "R", "author_id": "u1", "requested_reviewers": []string{"u2"}})
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("requested excluded reviewer: status=%d (want 409)", resp.StatusCode)
+	}
+
+	// уходит единственный обязательный ревьювер — замена из обязательных
+	resp = postJSON(t, srv.URL+"/pullRequest/reassign",
+		map[string]any{"pull_request_id": last.PullRequestID, "old_user_id": last.Assigned[0]})
+	var ra struct {
+		ReplacedBy string `json:"replaced_by"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&ra)
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusOK || !isMentor(ra.ReplacedBy) || ra.ReplacedBy == last.Assigned[0] {
+		t.Fatalf("reassign mentor: %d %+v", resp.StatusCode, ra)
+	}
+
+	resp, _ = http.Get(srv.URL + "/rules/list?team_name=backend")
+	var list struct {
+		Rules []api.ReviewerRule `json:"rules"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&list)
+	closeResp(t, resp)
+	if len(list.Rules) != 3 {
+		t.Fatalf("rules: %+v", list.Rules)
+	}
+	resp = postJSON(t, srv.URL+"/rules/delete", map[string]any{"id": excl.ID})
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete rule: status=%d", resp.StatusCode)
+	}
+	var n int64
+	db.Model(&model.ReviewerRuleDB{}).Count(&n)
+	if n != 2 {
+		t.Fatalf("rules after delete: %d", n)
+	}
+}
+
+func TestSeniority_CompositionOnCreateAndReassign(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	resp := postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name":            "backend",
+		"min_senior_reviewers": 1,
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true, "level": "junior"},
+			{"user_id": "j2", "username": "Bob", "is_active": true, "level": "junior"},
+			{"user_id": "j3", "username": "Carol", "is_active": true, "level": "junior"},
+			{"user_id": "j4", "username": "Dave", "is_active": true, "level": "junior"},
+			{"user_id": "s1", "username": "Sam", "is_active": true, "level": "senior"},
+			{"user_id": "s2", "username": "Sue", "is_active": true, "level": "senior"},
+		},
+	})
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("team/add: status=%d", resp.StatusCode)
+	}
+	isSenior := func(id string) bool { return id == "s1" || id == "s2" }
+
+	var prs []api.PullRequest
+	for i := 0; i < 6; i++ {
+		resp := postJSON(t, srv.URL+"/pullRequest/create", map[string]any{
+			"pull_request_id": fmt.Sprintf("pr-l%d", i), "pull_request_name": "L", "author_id": "u1"})
+		var out struct {
+			PR api.PullRequest `json:"pr"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		closeResp(t, resp)
+		if len(out.PR.Assigned) != 2 || !isSenior(out.PR.Assigned[0]) && !isSenior(out.PR.Assigned[1]) {
+			t.Fatalf("pr-l%d: no senior among %v", i, out.PR.Assigned)
+		}
+		prs = append(prs, out.PR)
+	}
+
+	// единственный senior уходит — замена тоже senior
+	for _, pr := range prs {
+		senior, other := pr.Assigned[0], pr.Assigned[1]
+		if !isSenior(senior) {
+			senior, other = other, senior
+		}
+		if isSenior(other) {
+			continue
+		}
+		resp := postJSON(t, srv.URL+"/pullRequest/reassign",
+			map[string]any{"pull_request_id": pr.PullRequestID, "old_user_id": senior})
+		var ra struct {
+			ReplacedBy string `json:"replaced_by"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&ra)
+		closeResp(t, resp)
+		if resp.StatusCode != http.StatusOK || !isSenior(ra.ReplacedBy) || ra.ReplacedBy == senior {
+			t.Fatalf("reassign %s on %s: %d %+v", senior, pr.PullRequestID, resp.StatusCode, ra)
+		}
+		break
+	}
+
+	resp = postJSON(t, srv.URL+"/users/setLevel", map[string]any{"user_id": "j2", "level": "senior"})
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("setLevel: status=%d", resp.StatusCode)
+	}
+	resp = postJSON(t, srv.URL+"/team/setComposition", map[string]any{"team_name": "backend", "min_senior_reviewers": 2})
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("setComposition: status=%d", resp.StatusCode)
+	}
+	resp, _ = http.Get(srv.URL + "/team/get?team_name=backend")
+	var team api.Team
+	_ = json.NewDecoder(resp.Body).Decode(&team)
+	closeResp(t, resp)
+	if team.MinSeniorReviewers != 2 {
+		t.Fatalf("team: %+v", team)
+	}
+	for _, m := range team.Members {
+		if m.UserID == "j2" && m.Level != "senior" {
+			t.Fatalf("j2 level: %+v", m)
+		}
+	}
+}
+
+func TestCapacity_SkipFullAndReassignAtCapacity(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db)
+	defer srv.Close()
+
+	resp := postJSON(t, srv.URL+"/team/add", map[string]any{
+		"team_name":        "backend",
+		"max_open_reviews": 1,
+		"members": []map[string]any{
+			{"user_id": "u1", "username": "Alice", "is_active": true},
+			{"user_id": "r1", "username": "Bob", "is_active": true},
+			{"user_id": "r2", "username": "Carol", "is_active": true},
+			{"user_id": "r3", "username": "Dave", "is_active": true},
+		},
+	})
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("team/add: status=%d", resp.StatusCode)
+	}
+	create := func(id string) api.PullRequest {
+		resp := postJSON(t, srv.URL+"/pullRequest/create", map[string]any{
+			"pull_request_id": id, "pull_request_name": "C", "author_id": "u1"})
+		var out struct {
+			PR api.PullRequest `json:"pr"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		closeResp(t, resp)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("create %s: status=%d", id, resp.StatusCode)
+		}
+		return out.PR
+	}
+
+	// у каждого место под одно ревью: второму PR достаётся только оставшийся, третьему — никто
+	pr1, pr2, pr3 := create("pr-c1"), create("pr-c2"), create("pr-c3")
+	if len(pr1.Assigned) != 2 || len(pr2.Assigned) != 1 || len(pr3.Assigned) != 0 {
+		t.Fatalf("assigned: %v %v %v", pr1.Assigned, pr2.Assigned, pr3.Assigned)
+	}
+	spare := pr2.Assigned[0]
+
+	resp = postJSON(t, srv.URL+"/pullRequest/reassign",
+		map[string]any{"pull_request_id": "pr-c1", "old_user_id": pr1.Assigned[0]})
+	var e api.ErrorResponse
+	_ = json.NewDecoder(resp.Body).Decode(&e)
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusConflict || e.Error.Code != "AT_CAPACITY" {
+		t.Fatalf("reassign at capacity: %d %+v", resp.StatusCode, e)
+	}
+
+	// собственный лимит важнее командного
+	resp = postJSON(t, srv.URL+"/users/setCapacity", map[string]any{"user_id": spare, "max_open_reviews": 2})
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("setCapacity: status=%d", resp.StatusCode)
+	}
+	resp = postJSON(t, srv.URL+"/pullRequest/reassign",
+		map[string]any{"pull_request_id": "pr-c1", "old_user_id": pr1.Assigned[0]})
+	var ra struct {
+		ReplacedBy string `json:"replaced_by"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&ra)
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusOK || ra.ReplacedBy != spare {
+		t.Fatalf("reassign: %d %+v (want %s)", resp.StatusCode, ra, spare)
+	}
+
+	// снятый лимит команды: свободны все, кроме автора
+	resp = postJSON(t, srv.URL+"/team/setCapacity", map[string]any{"team_name": "backend", "max_open_reviews": nil})
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("team/setCapacity: status=%d", resp.StatusCode)
+	}
+	if pr := create("pr-c4"); len(pr.Assigned) != 2 {
+		t.Fatalf("pr-c4 assigned: %v", pr.Assigned)
+	}
+
+	resp, _ = http.Get(srv.URL + "/team/get?team_name=backend")
+	var team api.Team
+	_ = json.NewDecoder(resp.Body).Decode(&team)
+	closeResp(t, resp)
+	if team.MaxOpenReviews != nil {
+		t.Fatalf("team limit: %v", *team.MaxOpenReviews)
+	}
+	for _, m := range team.Members {
+		if m.UserID == spare && (m.MaxOpenReviews == nil || *m.MaxOpenReviews != 2) {
+			t.Fatalf("%s limit: %+v", spare, m)
+		}
+	}
+}
+
+func TestSelectorSeed_ExactReviewers(t *testing.T) {
+	db := mustNewDB(t)
+	truncateAll(t, db)
+	srv := mustNewServer(t, db, api.WithTestMode())
+	defer srv.Close()
+
+	members := []map[string]any{{"user_id": "u1", "username": "Alice", "is_active": true}}
+	var cands []selector.Candidate
+	for i := 1; i <= 5; i++ {
+		id := fmt.Sprintf("r%d", i)
+		members = append(members, map[string]any{"user_id": id, "username": id, "is_active": true})
+		cands = append(cands, selector.Candidate{UserID: id})
+	}
+	resp := postJSON(t, srv.URL+"/team/add", map[string]any{"team_name": "backend", "members": members})
+	closeResp(t, resp)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("team/add: status=%d", resp.StatusCode)
+	}
+	// кандидаты приходят в порядке user_id, поэтому выбор можно повторить той же стратегией
+	expect := func(seed int64, cands []selector.Candidate, n int) []string {
+		var out []string
+		for _, c := range selector.Seeded(selector.NewRandom(), seed).Pick(cands, n) {
+			out = append(out, c.UserID)
+		}
+		return out
+	}
+	post := func(path string, seed int64, body any, out any) int {
+		b, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+path, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(api.SelectorSeedHeader, fmt.Sprint(seed))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST %s: %v", path, err)
+		}
+		_ = json.NewDecoder(resp.Body).Decode(out)
+		closeResp(t, resp)
+		return resp.StatusCode
+	}
+
+	want := expect(7, cands, 2)
+	for _, id := range []string{"pr-s1", "pr-s2"} {
+		var out struct {
+			PR api.PullRequest `json:"pr"`
+		}
+		code := post("/pullRequest/create", 7,
+			map[string]any{"pull_request_id": id, "pull_request_name": "S", "author_id": "u1"}, &out)
+		if code != http.StatusCreated || strings.Join(out.PR.Assigned, ",") != strings.Join(want, ",") {
+			t.Fatalf("create %s: %d %v (want %v)", id, code, out.PR.Assigned, want)
+		}
+	}
+
+	// замена — из оставшихся участников команды, тоже по зерну
+	var rest []selector.Candidate
+	for _, c := range cands {
+		if c.UserID != want[0] && c.UserID != want[1] {
+			rest = append(rest, c)
+		}
+	}
+	var ra struct {
+		ReplacedBy string `json:"replaced_by"`
+	}
+	code := post("/pullRequest/reassign", 11, map[string]any{"pull_request_id": "pr-s1", "old_user_id": want[0]}, &ra)
+	if w := expect(11, rest, 1); code != http.StatusOK || ra.ReplacedBy != w[0] {
+		t.Fatalf("reassign: %d %+v (want %s)", code, ra, w[0])
+	}
+}
@@ -0,0 +1,81 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+// readyTimeout ограничивает время каждой проверки готовности
+const readyTimeout = 2 * time.Second
+
+// DependencyStatus — результат проверки одной зависимости
+type DependencyStatus struct {
+	Status    string  `json:"status"` // ok | fail
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Version   *int    `json:"version,omitempty"`
+	Expected  *int    `json:"expected,omitempty"`
+}
+
+// Readiness — ответ /readyz
+type Readiness struct {
+	Status string                      `json:"status"` // ready | not_ready
+	Checks map[string]DependencyStatus `json:"checks"`
+}
+
+// Readyz обрабатывает GET /readyz
+// GET /readyz -> 200 {status:"ready", checks:{...}} | 503 {status:"not_ready", ...}
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	out := Readiness{Status: "ready", Checks: map[string]DependencyStatus{}}
+
+	out.Checks["database"] = check(r.Context(), func(ctx context.Context, st *DependencyStatus) error {
+		sqlDB, err := h.db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+	out.Checks["schema"] = check(r.Context(), func(ctx context.Context, st *DependencyStatus) error {
+		var v int
+		if err := h.db.WithContext(ctx).Model(&model.SchemaMigrationDB{}).
+			Select("COALESCE(MAX(version), 0)").Scan(&v).Error; err != nil {
+			return err
+		}
+		want := model.SchemaVersion
+		st.Version, st.Expected = &v, &want
+		if v < want {
+			return errSchemaOutdated
+		}
+		return nil
+	})
+
+	status := http.StatusOK
+	for name, c := range out.Checks {
+		if c.Status != "ok" {
+			out.Status, status = "not_ready", http.StatusServiceUnavailable
+			h.logger(r).Warn("dependency not ready", "dependency", name, "err", c.Error)
+		}
+	}
+	writeJSON(w, status, out)
+}
+
+// check выполняет проверку с таймаутом и замеряет её латентность
+func check(ctx context.Context, fn func(context.Context, *DependencyStatus) error) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	st := DependencyStatus{Status: "ok"}
+	start := time.Now()
+	err := fn(ctx, &st)
+	st.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		st.Status, st.Error = "fail", err.Error()
+	}
+	return st
+}
+
+var errSchemaOutdated = errors.New("schema version is older than expected, apply migrations")
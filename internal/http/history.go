@@ -0,0 +1,87 @@
+package httpapi
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+// recordEvent пишет событие в историю PR (pr_events); пустые user_id сохраняются как NULL
+func recordEvent(tx *gorm.DB, prID, kind, oldUserID, newUserID string) error {
+	return recordEventAt(tx, time.Now(), prID, kind, oldUserID, newUserID)
+}
+
+// recordEventAt — recordEvent с явным временем события (импорт истории)
+func recordEventAt(tx *gorm.DB, at time.Time, prID, kind, oldUserID, newUserID string) error {
+	ev := newEvent(at, prID, kind, oldUserID, newUserID)
+	return tx.Create(&ev).Error
+}
+
+// recordEventReason — recordEvent с причиной отказа ревьювера (пустая — без причины)
+func recordEventReason(tx *gorm.DB, prID, kind, oldUserID, newUserID, reason string) error {
+	ev := newEvent(time.Now(), prID, kind, oldUserID, newUserID)
+	if reason != "" {
+		ev.Reason = &reason
+	}
+	return tx.Create(&ev).Error
+}
+
+// recordUnfilled пишет снятие ревьювера, которому не нашлось замены (отказ, синхронизация ростера):
+// UNASSIGNED с причиной отказа (если есть) и NO_CANDIDATE с тем же временем.
+// По этой паре /stats отличает вынужденное снятие от ручного через setReviewers
+func recordUnfilled(tx *gorm.DB, prID, oldUserID, reason string) error {
+	now := time.Now()
+	ev := newEvent(now, prID, model.EventUnassigned, oldUserID, "")
+	if reason != "" {
+		ev.Reason = &reason
+	}
+	if err := tx.Create(&ev).Error; err != nil {
+		return err
+	}
+	return recordEventAt(tx, now, prID, model.EventNoCandidate, "", "")
+}
+
+func newEvent(at time.Time, prID, kind, oldUserID, newUserID string) model.PREventDB {
+	ev := model.PREventDB{PRID: prID, Kind: kind, CreatedAt: at}
+	if oldUserID != "" {
+		ev.OldUserID = &oldUserID
+	}
+	if newUserID != "" {
+		ev.NewUserID = &newUserID
+	}
+	return ev
+}
+
+// recordActivity пишет смену is_active пользователя (user_activity).
+// Вызывается при создании пользователя и при каждом изменении флага
+func recordActivity(tx *gorm.DB, userID string, active bool) error {
+	return tx.Create(&model.UserActivityDB{UserID: userID, IsActive: active, ChangedAt: time.Now()}).Error
+}
+
+// loadHistory собирает историю PR в хронологическом порядке
+func loadHistory(db *gorm.DB, prID string) (PRHistory, error) {
+	var rows []model.PREventDB
+	if err := db.Where("pr_id = ?", prID).Order("id").Find(&rows).Error; err != nil {
+		return PRHistory{}, err
+	}
+	out := PRHistory{Events: make([]PREvent, 0, len(rows))}
+	for _, ev := range rows {
+		if ev.Kind == model.EventReassigned || ev.Kind == model.EventUnassigned {
+			out.Reassignments++
+		}
+		e := PREvent{Kind: ev.Kind, At: ev.CreatedAt}
+		if ev.OldUserID != nil {
+			e.OldUserID = *ev.OldUserID
+		}
+		if ev.NewUserID != nil {
+			e.NewUserID = *ev.NewUserID
+		}
+		if ev.Reason != nil {
+			e.Reason = *ev.Reason
+		}
+		out.Events = append(out.Events, e)
+	}
+	return out, nil
+}
@@ -0,0 +1,176 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/auth"
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+const (
+	maxIdempotencyKey = 255
+	maxIdempotentBody = 8 << 20 // тело с ключом читается в память целиком, чтобы сверить хеш
+)
+
+// recordingWriter пишет ответ клиенту и копит его для сохранения под ключом
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap нужен http.ResponseController (Flush и т.п.)
+func (rw *recordingWriter) Unwrap() http.ResponseWriter { return rw.ResponseWriter }
+
+// Idempotent — middleware для изменяющих маршрутов с заголовком Idempotency-Key.
+//
+// Первый запрос с ключом выполняется, и его ответ (кроме 5xx) хранится idempotency.ttl;
+// повтор с тем же телом получает сохранённый ответ (тело, Content-Type и ETag)
+// с заголовком Idempotent-Replayed: true.
+// Тот же ключ с другим телом — 422 IDEMPOTENCY_KEY_REUSED, повтор во время
+// выполнения первого — 409 IDEMPOTENCY_IN_PROGRESS. Ключи различаются по вызывающему и маршруту.
+// Без заголовка запрос проходит как обычно
+func (h *Handler) Idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			writeErr(w, "BAD_REQUEST", "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeErr(w, "TOO_LARGE", "body is too large for Idempotency-Key (max "+
+					strconv.Itoa(maxIdempotentBody>>20)+" MiB)", http.StatusRequestEntityTooLarge)
+				return
+			}
+			writeErr(w, "BAD_REQUEST", "read body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		hash := hex.EncodeToString(sum[:])
+		scope := r.Method + " " + r.URL.Path
+		if p := auth.FromContext(r.Context()); p != nil {
+			scope = p.ID + " " + scope // Subject токена — лишь префикс, у разных токенов может совпасть
+		}
+
+		db := h.db.WithContext(r.Context())
+		// занять ключ; просроченная запись переиспользуется
+		res := db.Exec(`
+			INSERT INTO idempotency_keys (scope, key, request_hash, expires_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (scope, key) DO UPDATE SET request_hash = EXCLUDED.request_hash,
+				status_code = NULL, content_type = '', etag = '', body = NULL,
+				created_at = now(), expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at < now()`,
+			scope, key, hash, time.Now().Add(h.idemTTL))
+		if res.Error != nil {
+			h.dbErr(w, r, res.Error)
+			return
+		}
+		if res.RowsAffected == 0 {
+			h.replay(w, r, scope, key, hash)
+			return
+		}
+
+		rw := &recordingWriter{ResponseWriter: w}
+		// запись ключа переживает отмену запроса: ответ клиенту уже ушёл
+		bg := h.db.WithContext(context.WithoutCancel(r.Context()))
+		row := func() *gorm.DB { return bg.Where("scope = ? AND key = ?", scope, key) }
+		done := false
+		defer func() {
+			if done {
+				return
+			}
+			// паника или 5xx: ключ освобождается, повтор выполнит запрос заново
+			if err := row().Delete(&model.IdempotencyKeyDB{}).Error; err != nil {
+				h.logger(r).Error("idempotency key release failed", "err", err)
+			}
+		}()
+		next.ServeHTTP(rw, r)
+		if rw.status == 0 || rw.status >= 500 {
+			return
+		}
+		done = true
+		if err := row().Model(&model.IdempotencyKeyDB{}).Updates(map[string]any{
+			"status_code":  rw.status,
+			"content_type": rw.Header().Get("Content-Type"),
+			"etag":         rw.Header().Get("ETag"),
+			"body":         rw.body.Bytes(),
+		}).Error; err != nil {
+			h.logger(r).Error("idempotency key save failed", "err", err)
+		}
+	})
+}
+
+// replay отдаёт сохранённый ответ для занятого ключа
+func (h *Handler) replay(w http.ResponseWriter, r *http.Request, scope, key, hash string) {
+	var saved model.IdempotencyKeyDB
+	if err := h.db.WithContext(r.Context()).
+		First(&saved, "scope = ? AND key = ?", scope, key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// первый запрос упал и освободил ключ между INSERT и чтением — можно повторить
+			w.Header().Set("Retry-After", "1")
+			writeErr(w, "IDEMPOTENCY_IN_PROGRESS", "previous request with this Idempotency-Key failed, retry",
+				http.StatusConflict)
+			return
+		}
+		h.dbErr(w, r, err)
+		return
+	}
+	switch {
+	case saved.RequestHash != hash:
+		writeErr(w, "IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was used with a different request body",
+			http.StatusUnprocessableEntity)
+	case saved.StatusCode == nil:
+		w.Header().Set("Retry-After", "1")
+		writeErr(w, "IDEMPOTENCY_IN_PROGRESS", "request with this Idempotency-Key is still running",
+			http.StatusConflict)
+	default:
+		if saved.ContentType != "" {
+			w.Header().Set("Content-Type", saved.ContentType)
+		}
+		if saved.ETag != "" {
+			w.Header().Set("ETag", saved.ETag)
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(*saved.StatusCode)
+		_, _ = w.Write(saved.Body)
+	}
+}
+
+// PurgeIdempotencyKeys удаляет просроченные ключи; возвращает число удалённых
+func (h *Handler) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	res := h.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&model.IdempotencyKeyDB{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,244 @@
+package httpapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+const (
+	defaultImportBatch = 500
+	maxImportBatch     = 5000
+	maxImportLine      = 1 << 20 // 1 MiB на строку NDJSON
+	maxImportReviewers = 2       // слотов ревьюверов в схеме; текущая настройка selector.reviewers не ограничивает историю
+)
+
+// importRow — строка NDJSON для POST /pullRequest/import
+type importRow struct {
+	ID        string     `json:"pull_request_id"`
+	Name      string     `json:"pull_request_name"`
+	AuthorID  string     `json:"author_id"`
+	Status    string     `json:"status"`
+	CreatedAt *time.Time `json:"created_at"`
+	MergedAt  *time.Time `json:"merged_at"`
+	Reviewers []string   `json:"reviewers"`
+
+	line int
+}
+
+// importError — ошибка конкретной строки; строки с ошибкой не импортируются
+type importError struct {
+	code, msg string
+}
+
+func (e *importError) Error() string { return e.msg }
+
+// validate проверяет строку без БД и нормализует статус
+func (row *importRow) validate() error {
+	bad := func(format string, args ...any) error {
+		return &importError{"BAD_REQUEST", fmt.Sprintf(format, args...)}
+	}
+	if row.ID == "" || row.Name == "" || row.AuthorID == "" {
+		return bad("pull_request_id, pull_request_name and author_id are required")
+	}
+	if row.CreatedAt == nil {
+		return bad("created_at is required")
+	}
+	if row.Status == "" {
+		row.Status = "OPEN"
+		if row.MergedAt != nil {
+			row.Status = "MERGED"
+		}
+	}
+	switch row.Status {
+	case "OPEN":
+		if row.MergedAt != nil {
+			return bad("merged_at is set for OPEN PR")
+		}
+	case "MERGED":
+		if row.MergedAt == nil {
+			return bad("merged_at is required for MERGED PR")
+		}
+		if row.MergedAt.Before(*row.CreatedAt) {
+			return bad("merged_at is before created_at")
+		}
+	default:
+		return bad("status must be OPEN or MERGED")
+	}
+	if len(row.Reviewers) > maxImportReviewers {
+		return bad("at most %d reviewers", maxImportReviewers)
+	}
+	seen := map[string]bool{}
+	for _, rv := range row.Reviewers {
+		if rv == "" || rv == row.AuthorID || seen[rv] {
+			return bad("reviewers must be distinct, non-empty and not the author")
+		}
+		seen[rv] = true
+	}
+	return nil
+}
+
+// PRImport обрабатывает POST /pullRequest/import
+// POST /pullRequest/import?batch_size=N (тело — NDJSON) -> 200 {imported, failed, errors:[...]} | 400 | 403
+//
+// Каждая строка — PR с историческими created_at/merged_at и готовым списком ревьюверов
+// (стратегия выбора не вызывается). Строки пишутся пачками по batch_size в транзакции;
+// ошибочная строка откатывается до savepoint и попадает в errors, остальные пачки это не трогает.
+// В историю пишутся CREATED/ASSIGNED/MERGED с историческим временем — их видят /stats/*
+func (h *Handler) PRImport(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	batch := defaultImportBatch
+	if v := r.URL.Query().Get("batch_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxImportBatch {
+			writeErr(w, "BAD_REQUEST", fmt.Sprintf("batch_size must be in 1..%d", maxImportBatch), http.StatusBadRequest)
+			return
+		}
+		batch = n
+	}
+	// импорт пишет PR любых команд
+	if !h.allowTeam(w, r, "") {
+		return
+	}
+
+	res := ImportResult{Errors: []ImportRowError{}}
+	fail := func(row importRow, err error) {
+		e := ImportRowError{Line: row.line, PullRequestID: row.ID}
+		var ie *importError
+		if errors.As(err, &ie) {
+			e.Code, e.Message = ie.code, ie.msg
+		} else {
+			// текст ошибки БД клиенту не отдаём, как в dbErr
+			h.logger(r).Error("import row failed", "line", row.line, "err", err)
+			e.Code, e.Message = "INTERNAL", "db error"
+		}
+		res.Failed++
+		res.Errors = append(res.Errors, e)
+	}
+	flush := func(rows []importRow) {
+		if len(rows) == 0 {
+			return
+		}
+		// ошибки строк учитываем только после коммита: при откате пачки они не нужны
+		imported := 0
+		var rowErrs []error
+		err := db.Transaction(func(tx *gorm.DB) error {
+			imported, rowErrs = 0, make([]error, len(rows))
+			for i, row := range rows {
+				sp := "import_row_" + strconv.Itoa(i)
+				if err := tx.SavePoint(sp).Error; err != nil {
+					return err
+				}
+				if err := importPR(tx, row); err != nil {
+					if rbErr := tx.RollbackTo(sp).Error; rbErr != nil {
+						return rbErr
+					}
+					rowErrs[i] = err
+					continue
+				}
+				imported++
+			}
+			return nil
+		})
+		if err != nil {
+			// пачка не записалась целиком
+			h.logger(r).Error("import batch failed", "first_line", rows[0].line, "err", err)
+			for _, row := range rows {
+				fail(row, &importError{"INTERNAL", "batch failed: db error"})
+			}
+			return
+		}
+		res.Imported += imported
+		for i, err := range rowErrs {
+			if err != nil {
+				fail(rows[i], err)
+			}
+		}
+	}
+
+	sc := bufio.NewScanner(r.Body)
+	sc.Buffer(make([]byte, 64*1024), maxImportLine)
+	var pending []importRow
+	line := 0
+	for sc.Scan() {
+		line++
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		row := importRow{line: line}
+		if err := json.Unmarshal(sc.Bytes(), &row); err != nil {
+			fail(row, &importError{"BAD_REQUEST", "invalid json"})
+			continue
+		}
+		if err := row.validate(); err != nil {
+			fail(row, err)
+			continue
+		}
+		pending = append(pending, row)
+		if len(pending) == batch {
+			flush(pending)
+			pending = pending[:0]
+		}
+	}
+	flush(pending)
+	if err := sc.Err(); err != nil {
+		// остаток тела не прочитан; то, что успели, уже записано
+		fail(importRow{line: line + 1}, &importError{"BAD_REQUEST", "read body: " + err.Error()})
+	}
+	sort.SliceStable(res.Errors, func(i, j int) bool { return res.Errors[i].Line < res.Errors[j].Line })
+	writeJSON(w, http.StatusOK, res)
+}
+
+// importPR вставляет один PR с ревьюверами и историей внутри tx
+func importPR(tx *gorm.DB, row importRow) error {
+	var cnt int64
+	if err := tx.Model(&model.PullRequestDB{}).Where("pull_request_id = ?", row.ID).Count(&cnt).Error; err != nil {
+		return err
+	}
+	if cnt > 0 {
+		return &importError{"PR_EXISTS", "PR id already exists"}
+	}
+	ids := append([]string{row.AuthorID}, row.Reviewers...)
+	if err := tx.Model(&model.UserDB{}).Where("user_id IN ?", ids).Count(&cnt).Error; err != nil {
+		return err
+	}
+	if cnt != int64(len(ids)) {
+		return &importError{"NOT_FOUND", "author or reviewer not found"}
+	}
+
+	pr := model.PullRequestDB{
+		ID:        row.ID,
+		Name:      row.Name,
+		AuthorID:  row.AuthorID,
+		Status:    row.Status,
+		CreatedAt: *row.CreatedAt,
+		MergedAt:  row.MergedAt,
+	}
+	if err := tx.Create(&pr).Error; err != nil {
+		return err
+	}
+	if err := recordEventAt(tx, *row.CreatedAt, pr.ID, model.EventCreated, "", ""); err != nil {
+		return err
+	}
+	for i, rv := range row.Reviewers {
+		if err := tx.Create(&model.PRReviewerDB{PRID: pr.ID, ReviewerID: rv, Position: int16(i + 1)}).Error; err != nil {
+			return err
+		}
+		if err := recordEventAt(tx, *row.CreatedAt, pr.ID, model.EventAssigned, "", rv); err != nil {
+			return err
+		}
+	}
+	if row.MergedAt != nil {
+		return recordEventAt(tx, *row.MergedAt, pr.ID, model.EventMerged, "", "")
+	}
+	return nil
+}
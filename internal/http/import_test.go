@@ -0,0 +1,57 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	api "github.com/alinaaved/pr-reviewer/internal/http"
+	"github.com/alinaaved/pr-reviewer/internal/selector"
+)
+
+func TestPRImport_RowValidation(t *testing.T) {
+	// лимит ревьюверов в истории — по схеме, а не по текущей настройке
+	h := dryRunHandler(t, api.WithSelector(selector.NewRandom(), 1))
+	assertBadRequestPost := func(q string) {
+		rec := httptest.NewRecorder()
+		h.PRImport(rec, httptest.NewRequest(http.MethodPost, "/pullRequest/import?"+q, strings.NewReader("")))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status=%d (want 400)", q, rec.Code)
+		}
+	}
+	assertBadRequestPost("batch_size=0")
+	assertBadRequestPost("batch_size=x")
+
+	// все строки невалидны — до БД не доходит, ошибки по номерам строк
+	body := strings.Join([]string{
+		`{"pull_request_id":"p1","pull_request_name":"n","author_id":"u1"}`,
+		`not json`,
+		``,
+		`{"pull_request_id":"p2","pull_request_name":"n","author_id":"u1","created_at":"2025-01-02T00:00:00Z","merged_at":"2025-01-01T00:00:00Z"}`,
+		`{"pull_request_id":"p3","pull_request_name":"n","author_id":"u1","created_at":"2025-01-01T00:00:00Z","reviewers":["u1"]}`,
+		`{"pull_request_id":"p4","pull_request_name":"n","author_id":"u1","created_at":"2025-01-01T00:00:00Z","status":"CLOSED"}`,
+		`{"pull_request_id":"p5","pull_request_name":"n","author_id":"u1","created_at":"2025-01-01T00:00:00Z","reviewers":["u2","u3","u4"]}`,
+	}, "\n")
+	rec := httptest.NewRecorder()
+	h.PRImport(rec, httptest.NewRequest(http.MethodPost, "/pullRequest/import", strings.NewReader(body)))
+	var res api.ImportResult
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status=%d err=%v", rec.Code, err)
+	}
+	var lines []int
+	for _, e := range res.Errors {
+		if e.Code != "BAD_REQUEST" {
+			t.Fatalf("line %d: code %s", e.Line, e.Code)
+		}
+		lines = append(lines, e.Line)
+	}
+	if res.Imported != 0 || res.Failed != 6 || fmt.Sprint(lines) != "[1 2 4 5 6 7]" {
+		t.Fatalf("result: %+v", res)
+	}
+	if last := res.Errors[len(res.Errors)-1]; last.Message != "at most 2 reviewers" {
+		t.Fatalf("line %d: %s", last.Line, last.Message)
+	}
+}
@@ -0,0 +1,118 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+// userLevels — допустимые уровни пользователей
+var userLevels = map[string]bool{model.LevelJunior: true, model.LevelMid: true, model.LevelSenior: true}
+
+// validateComposition проверяет уровни и лимиты участников, требование команды к числу senior
+// и лимит команды
+func (h *Handler) validateComposition(t Team) error {
+	if t.MinSeniorReviewers < 0 || t.MinSeniorReviewers > h.reviewers {
+		return fmt.Errorf("min_senior_reviewers must be within 0..%d", h.reviewers)
+	}
+	if t.MaxOpenReviews != nil && *t.MaxOpenReviews <= 0 {
+		return errors.New("max_open_reviews must be positive")
+	}
+	for _, m := range t.Members {
+		if m.Level != "" && !userLevels[m.Level] {
+			return fmt.Errorf("user %s: level must be junior, mid or senior", m.UserID)
+		}
+		if m.MaxOpenReviews != nil && *m.MaxOpenReviews <= 0 {
+			return fmt.Errorf("user %s: max_open_reviews must be positive", m.UserID)
+		}
+	}
+	return nil
+}
+
+// UsersSetLevel обрабатывает POST /users/setLevel
+// POST /users/setLevel { user_id, level } -> 200 {user:{...}} | 400 | 403 | 404
+//
+// Уровень учитывается при следующих назначениях; текущие ревьюверы не меняются
+func (h *Handler) UsersSetLevel(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	var in struct {
+		UserID string `json:"user_id"`
+		Level  string `json:"level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeErr(w, "BAD_REQUEST", "invalid json", http.StatusBadRequest)
+		return
+	}
+	if !userLevels[in.Level] {
+		writeErr(w, "BAD_REQUEST", "level must be junior, mid or senior", http.StatusBadRequest)
+		return
+	}
+
+	var u model.UserDB
+	if err := db.First(&u, "user_id = ?", in.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeErr(w, "NOT_FOUND", "user not found", http.StatusNotFound)
+			return
+		}
+		h.dbErr(w, r, err)
+		return
+	}
+	if !h.allowTeam(w, r, u.TeamName) {
+		return
+	}
+	if err := db.Model(&u).Update("level", in.Level).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"user": User{
+			UserID:         u.UserID,
+			Username:       u.Username,
+			TeamName:       u.TeamName,
+			IsActive:       u.IsActive,
+			Level:          in.Level,
+			MaxOpenReviews: u.MaxOpenReviews,
+		},
+	})
+}
+
+// TeamSetComposition обрабатывает POST /team/setComposition
+// POST /team/setComposition { team_name, min_senior_reviewers } -> 200 {team_name, min_senior_reviewers} | 400 | 403 | 404
+//
+// Требование действует для новых назначений на PR авторов команды: создание, переназначение,
+// отказ и дозаполнение слотов; если активных senior не хватает, назначаются остальные
+func (h *Handler) TeamSetComposition(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	var in Team
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.TeamName == "" {
+		writeErr(w, "BAD_REQUEST", "team_name is required", http.StatusBadRequest)
+		return
+	}
+	in.Members = nil
+	if err := h.validateComposition(in); err != nil {
+		writeErr(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !h.allowTeam(w, r, in.TeamName) {
+		return
+	}
+	res := db.Model(&model.TeamDB{}).Where("team_name = ?", in.TeamName).
+		Update("min_senior_reviewers", in.MinSeniorReviewers)
+	if res.Error != nil {
+		h.dbErr(w, r, res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		writeErr(w, "NOT_FOUND", "team not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"team_name":            in.TeamName,
+		"min_senior_reviewers": in.MinSeniorReviewers,
+	})
+}
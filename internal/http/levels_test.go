@@ -0,0 +1,30 @@
+package httpapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevels_BadRequest(t *testing.T) {
+	h := dryRunHandler(t)
+	cases := []struct {
+		path string
+		fn   http.HandlerFunc
+		body string
+	}{
+		{"/users/setLevel", h.UsersSetLevel, `{"user_id":"u1","level":"lead"}`},
+		{"/team/setComposition", h.TeamSetComposition, `{"min_senior_reviewers":1}`},
+		{"/team/setComposition", h.TeamSetComposition, `{"team_name":"backend","min_senior_reviewers":3}`},
+		{"/team/add", h.TeamAdd, `{"team_name":"backend","members":[{"user_id":"u1","username":"A","level":"lead"}]}`},
+		{"/team/add", h.TeamAdd, `{"team_name":"backend","min_senior_reviewers":-1}`},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		tc.fn(rec, httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s %s: status=%d (want 400)", tc.path, tc.body, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package httpapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// listSorts — допустимые значения sort и соответствующие колонки.
+// Вторым ключом всегда идёт pull_request_id — он делает порядок строгим для курсора
+var listSorts = map[string]string{
+	"created_at":      "pr.created_at",
+	"pull_request_id": "pr.pull_request_id",
+}
+
+// prListQuery — разобранные параметры GET /pullRequest/list
+type prListQuery struct {
+	Status      string
+	AuthorID    string
+	ReviewerID  string
+	TeamName    string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MergedFrom  *time.Time
+	MergedTo    *time.Time
+	SortField   string
+	Desc        bool
+	Limit       int
+	After       *listCursor
+}
+
+// listCursor — позиция последнего отданного PR: значение ключа сортировки и id
+type listCursor struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func encodeCursor(c listCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*listCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var c listCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return nil, errors.New("invalid cursor")
+	}
+	return &c, nil
+}
+
+// parseListQuery разбирает и проверяет параметры запроса
+func parseListQuery(q url.Values) (prListQuery, error) {
+	out := prListQuery{
+		Status:     strings.ToUpper(q.Get("status")), // регистр не важен, как в /users/getReview
+		AuthorID:   q.Get("author_id"),
+		ReviewerID: q.Get("reviewer_id"),
+		TeamName:   q.Get("team_name"),
+		SortField:  "created_at",
+		Desc:       true,
+		Limit:      defaultListLimit,
+	}
+	if out.Status != "" && out.Status != "OPEN" && out.Status != "MERGED" {
+		return out, errors.New("status must be OPEN or MERGED")
+	}
+
+	times := []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_from", &out.CreatedFrom},
+		{"created_to", &out.CreatedTo},
+		{"merged_from", &out.MergedFrom},
+		{"merged_to", &out.MergedTo},
+	}
+	for _, t := range times {
+		v := q.Get(t.name)
+		if v == "" {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return out, fmt.Errorf("%s must be RFC3339", t.name)
+		}
+		*t.dst = &ts
+	}
+
+	if s := q.Get("sort"); s != "" {
+		out.Desc = strings.HasPrefix(s, "-")
+		out.SortField = strings.TrimPrefix(s, "-")
+		if _, ok := listSorts[out.SortField]; !ok {
+			return out, errors.New("sort must be one of created_at, pull_request_id (prefix - for descending)")
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListLimit {
+			return out, fmt.Errorf("limit must be in 1..%d", maxListLimit)
+		}
+		out.Limit = n
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil {
+			return out, err
+		}
+		if out.SortField == "created_at" {
+			if _, err := time.Parse(time.RFC3339Nano, c.Value); err != nil {
+				return out, errors.New("invalid cursor")
+			}
+		}
+		out.After = c
+	}
+	return out, nil
+}
+
+// PRList обрабатывает GET /pullRequest/list
+// GET /pullRequest/list?status=&author_id=&reviewer_id=&team_name=&created_from=&created_to=
+// &merged_from=&merged_to=&sort=&limit=&cursor= -> 200 {pull_requests:[...], next_cursor} | 400
+//
+// team_name фильтрует по команде автора. Пагинация курсорная (keyset):
+// next_cursor отдаётся, пока есть следующая страница
+func (h *Handler) PRList(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	in, err := parseListQuery(r.URL.Query())
+	if err != nil {
+		writeErr(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := db.Table("pull_requests AS pr").Select("pr.*")
+	if in.Status != "" {
+		q = q.Where("pr.status = ?", in.Status)
+	}
+	if in.AuthorID != "" {
+		q = q.Where("pr.author_id = ?", in.AuthorID)
+	}
+	if in.ReviewerID != "" {
+		q = q.Where("EXISTS (SELECT 1 FROM pr_reviewers r WHERE r.pr_id = pr.pull_request_id AND r.reviewer_id = ?)", in.ReviewerID)
+	}
+	if in.TeamName != "" {
+		q = q.Joins("JOIN users a ON a.user_id = pr.author_id").Where("a.team_name = ?", in.TeamName)
+	}
+	if in.CreatedFrom != nil {
+		q = q.Where("pr.created_at >= ?", *in.CreatedFrom)
+	}
+	if in.CreatedTo != nil {
+		q = q.Where("pr.created_at < ?", *in.CreatedTo)
+	}
+	if in.MergedFrom != nil {
+		q = q.Where("pr.merged_at >= ?", *in.MergedFrom)
+	}
+	if in.MergedTo != nil {
+		q = q.Where("pr.merged_at < ?", *in.MergedTo)
+	}
+
+	col := listSorts[in.SortField]
+	dir, cmp := "ASC", ">"
+	if in.Desc {
+		dir, cmp = "DESC", "<"
+	}
+	if in.After != nil {
+		if in.SortField == "pull_request_id" {
+			q = q.Where("pr.pull_request_id "+cmp+" ?", in.After.ID)
+		} else {
+			v, _ := time.Parse(time.RFC3339Nano, in.After.Value)
+			q = q.Where("("+col+", pr.pull_request_id) "+cmp+" (?, ?)", v, in.After.ID)
+		}
+	}
+	if col == "pr.pull_request_id" {
+		q = q.Order("pr.pull_request_id " + dir)
+	} else {
+		q = q.Order(col + " " + dir).Order("pr.pull_request_id " + dir)
+	}
+
+	// берём на одну строку больше, чтобы понять, есть ли следующая страница
+	var prs []model.PullRequestDB
+	if err := q.Limit(in.Limit + 1).Find(&prs).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	var next string
+	if len(prs) > in.Limit {
+		prs = prs[:in.Limit]
+		last := prs[len(prs)-1]
+		c := listCursor{ID: last.ID}
+		if in.SortField == "created_at" {
+			c.Value = last.CreatedAt.Format(time.RFC3339Nano)
+		}
+		next = encodeCursor(c)
+	}
+
+	reviewers, err := loadReviewers(db, prs)
+	if err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	list := make([]PullRequest, 0, len(prs))
+	for i := range prs {
+		pr := prs[i]
+		assigned := reviewers[pr.ID]
+		if assigned == nil {
+			assigned = []string{}
+		}
+		list = append(list, PullRequest{
+			PullRequestID:   pr.ID,
+			PullRequestName: pr.Name,
+			AuthorID:        pr.AuthorID,
+			Status:          pr.Status,
+			Assigned:        assigned,
+			CreatedAt:       &pr.CreatedAt,
+			MergedAt:        pr.MergedAt,
+			Version:         pr.Version,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"pull_requests": list,
+		"next_cursor":   next,
+	})
+}
+
+// loadReviewers одним запросом достаёт ревьюверов для списка PR (по позициям слотов)
+func loadReviewers(db *gorm.DB, prs []model.PullRequestDB) (map[string][]string, error) {
+	out := make(map[string][]string, len(prs))
+	if len(prs) == 0 {
+		return out, nil
+	}
+	ids := make([]string, 0, len(prs))
+	for _, pr := range prs {
+		ids = append(ids, pr.ID)
+	}
+	var rows []model.PRReviewerDB
+	if err := db.
+		Where("pr_id IN ?", ids).
+		Order("pr_id, position").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		out[r.PRID] = append(out[r.PRID], r.ReviewerID)
+	}
+	return out, nil
+}
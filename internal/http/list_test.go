@@ -0,0 +1,70 @@
+package httpapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPRList_BadQuery(t *testing.T) {
+	assertBadRequest(t, dryRunHandler(t).PRList, "/pullRequest/list", []string{
+		"status=CLOSED",
+		"created_from=yesterday",
+		"sort=author_id",
+		"limit=0",
+		"limit=1000",
+		"cursor=!!!",
+		"cursor=e30", // {} без id
+	})
+}
+
+func TestUsersGetReview_BadQuery(t *testing.T) {
+	assertBadRequest(t, dryRunHandler(t).UsersGetReview, "/users/getReview", []string{
+		"",
+		"user_id=u1&status=CLOSED",
+		"user_id=u1&limit=-1",
+		"user_id=u1&include=comments",
+		"user_id=u1&cursor=eyJpZCI6InByLTEifQ", // {"id":"pr-1"} без времени
+	})
+}
+
+func TestStats_BadQuery(t *testing.T) {
+	h := dryRunHandler(t)
+	queries := []string{
+		"from=last-week",
+		"to=2025-01-01",
+		"from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z",
+	}
+	assertBadRequest(t, h.StatsReviewers, "/stats/reviewers", queries)
+	assertBadRequest(t, h.StatsAuthors, "/stats/authors", queries)
+	assertBadRequest(t, h.StatsSummary, "/stats/summary", queries)
+}
+
+func TestStatsFairness_BadQuery(t *testing.T) {
+	assertBadRequest(t, dryRunHandler(t).StatsFairness, "/stats/fairness", []string{
+		"",
+		"team_name=backend&outlier_ratio=0.5",
+		"team_name=backend&outlier_ratio=x",
+		"team_name=backend&format=xml",
+		"team_name=backend&from=yesterday",
+	})
+}
+
+func TestExport_BadQuery(t *testing.T) {
+	srv := httptest.NewServer(dryRunHandler(t).Routes())
+	defer srv.Close()
+	for q, want := range map[string]int{
+		"/export/users":                    http.StatusNotFound,
+		"/export/pullRequests?format=xlsx": http.StatusBadRequest,
+		"/export/events?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z": http.StatusBadRequest,
+	} {
+		resp, err := http.Get(srv.URL + q)
+		if err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("%s: status=%d (want %d)", q, resp.StatusCode, want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/alinaaved/pr-reviewer/internal/httpx"
+	"github.com/alinaaved/pr-reviewer/internal/selector"
+)
+
+// RequestIDHeader — заголовок с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestIDFromContext возвращает идентификатор запроса ("" если его нет)
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID берёт X-Request-ID из запроса (или генерирует новый),
+// кладёт его в контекст и возвращает в ответе
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			var b [16]byte
+			_, _ = rand.Read(b[:])
+			id = hex.EncodeToString(b[:])
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// SelectorSeedHeader — заголовок с зерном выбора ревьюверов для запроса (только WithTestMode)
+const SelectorSeedHeader = "X-Selector-Seed"
+
+type selectorKey struct{}
+
+// SelectorSeed в тестовом режиме берёт зерно из X-Selector-Seed и кладёт в контекст стратегию,
+// засеянную им: один и тот же запрос к одним и тем же данным назначает тех же ревьюверов.
+// Без тестового режима заголовок игнорируется
+func (h *Handler) SelectorSeed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := r.Header.Get(SelectorSeedHeader)
+		if !h.testMode || v == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		seed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || seed == 0 {
+			writeErr(w, "BAD_REQUEST", SelectorSeedHeader+" must be a non-zero integer", http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), selectorKey{}, selector.Seeded(h.sel, seed))))
+	})
+}
+
+// strategy — стратегия выбора ревьюверов для запроса: засеянная из X-Selector-Seed или общая
+func (h *Handler) strategy(r *http.Request) selector.Strategy {
+	if s, ok := r.Context().Value(selectorKey{}).(selector.Strategy); ok {
+		return s
+	}
+	return h.sel
+}
+
+// logger возвращает логгер с request_id (и trace_id, если есть трейс) текущего запроса
+func (h *Handler) logger(r *http.Request) *slog.Logger {
+	l := h.log
+	if id := RequestIDFromContext(r.Context()); id != "" {
+		l = l.With("request_id", id)
+	}
+	if sc := trace.SpanContextFromContext(r.Context()); sc.IsSampled() {
+		l = l.With("trace_id", sc.TraceID().String())
+	}
+	return l
+}
+
+// LogRequests пишет по строке лога на запрос: маршрут, статус, латентность
+func (h *Handler) LogRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := httpx.Wrap(w)
+		next.ServeHTTP(sw, r)
+
+		attrs := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", sw.Status(),
+			"bytes", sw.Bytes(),
+			"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+			attrs = append(attrs, "route", rc.RoutePattern())
+		}
+		level := slog.LevelInfo
+		if sw.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		h.logger(r).Log(r.Context(), level, "http request", attrs...)
+	})
+}
+
+// Recover перехватывает панику в обработчике, логирует её со стеком
+// и отвечает 500 ErrorResponse, если ответ ещё не начат
+func (h *Handler) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := httpx.Wrap(w)
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			h.logger(r).Error("panic recovered", "panic", rec, "stack", string(debug.Stack()))
+			if sw.Written() {
+				// заголовки уже ушли клиенту — второй ответ только испортил бы тело
+				return
+			}
+			writeErr(sw, "INTERNAL", "internal error", http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(sw, r)
+	})
+}
@@ -0,0 +1,146 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	api "github.com/alinaaved/pr-reviewer/internal/http"
+)
+
+func TestMiddleware_RequestIDAndRecover(t *testing.T) {
+	var logs bytes.Buffer
+	h := api.NewHandler(nil, api.WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+
+	r := chi.NewRouter()
+	r.Use(api.RequestID, h.LogRequests, h.Recover)
+	r.Get("/boom", func(http.ResponseWriter, *http.Request) { panic("kaboom") })
+
+	req := httptest.NewRequest(http.MethodGet, "/boom", nil)
+	req.Header.Set(api.RequestIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status=%d (want 500)", rec.Code)
+	}
+	if got := rec.Header().Get(api.RequestIDHeader); got != "req-42" {
+		t.Fatalf("request id=%q (want req-42)", got)
+	}
+	var body api.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Error.Code != "INTERNAL" {
+		t.Fatalf("body is not ErrorResponse: %v %+v", err, body)
+	}
+
+	out := logs.String()
+	for _, want := range []string{`"panic":"kaboom"`, `"request_id":"req-42"`, `"status":500`, `"route":"/boom"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("log has no %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestMiddleware_RecoverAfterHeadersSent(t *testing.T) {
+	var logs bytes.Buffer
+	h := api.NewHandler(nil, api.WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+
+	r := chi.NewRouter()
+	r.Use(h.LogRequests, h.Recover)
+	r.Get("/partial", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("partial"))
+		panic("late")
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/partial", nil))
+	// ответ уже начат — Recover только логирует панику, тело не дописывает
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "partial" {
+		t.Fatalf("status=%d body=%q", rec.Code, rec.Body.String())
+	}
+	if out := logs.String(); !strings.Contains(out, `"panic":"late"`) || !strings.Contains(out, `"status":202`) {
+		t.Fatalf("log:\n%s", out)
+	}
+}
+
+func TestMiddleware_GeneratesRequestID(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(api.RequestID)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(api.RequestIDFromContext(r.Context())))
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	id := rec.Header().Get(api.RequestIDHeader)
+	if id == "" || rec.Body.String() != id {
+		t.Fatalf("header=%q body=%q", id, rec.Body.String())
+	}
+}
+
+func TestMiddleware_IdempotentRejectsBeforeDB(t *testing.T) {
+	// до БД дело не доходит: запрос без ключа проходит как есть, длинный ключ и большое тело отклоняются
+	h := api.NewHandler(nil)
+	called := 0
+	next := h.Idempotent(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called++
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	rec := httptest.NewRecorder()
+	next.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader("{}")))
+	if rec.Code != http.StatusCreated || called != 1 {
+		t.Fatalf("no key: status=%d called=%d", rec.Code, called)
+	}
+
+	for _, tc := range []struct {
+		key  string
+		body string
+		want int
+	}{
+		{strings.Repeat("k", 256), "{}", http.StatusBadRequest},
+		{"k-1", strings.Repeat("x", 8<<20+1), http.StatusRequestEntityTooLarge},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(tc.body))
+		req.Header.Set("Idempotency-Key", tc.key)
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, req)
+		if rec.Code != tc.want || called != 1 {
+			t.Fatalf("key len %d: status=%d called=%d (want %d)", len(tc.key), rec.Code, called, tc.want)
+		}
+	}
+}
+
+func TestSelectorSeed_Header(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	cases := []struct {
+		testMode bool
+		seed     string
+		want     int
+	}{
+		{true, "42", http.StatusNoContent},
+		{true, "", http.StatusNoContent},
+		{true, "abc", http.StatusBadRequest},
+		{true, "0", http.StatusBadRequest},
+		{false, "abc", http.StatusNoContent}, // вне тестового режима заголовок игнорируется
+	}
+	for _, tc := range cases {
+		var opts []api.Option
+		if tc.testMode {
+			opts = append(opts, api.WithTestMode())
+		}
+		req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", nil)
+		req.Header.Set(api.SelectorSeedHeader, tc.seed)
+		rec := httptest.NewRecorder()
+		dryRunHandler(t, opts...).SelectorSeed(next).ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Fatalf("test_mode=%v seed=%q: status=%d (want %d)", tc.testMode, tc.seed, rec.Code, tc.want)
+		}
+	}
+}
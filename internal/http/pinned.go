@@ -0,0 +1,209 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+// reviewerError — запрошенный ревьювер не подходит; пишется клиенту как есть
+type reviewerError struct {
+	status    int
+	code, msg string
+}
+
+func (e *reviewerError) Error() string { return e.msg }
+
+func (e *reviewerError) write(w http.ResponseWriter) { writeErr(w, e.code, e.msg, e.status) }
+
+// checkRequested проверяет ревьюверов, выбранных вручную: не больше h.reviewers, без повторов,
+// не автор, существуют, активны и не исключены правилом EXCLUDE. Команда не проверяется —
+// автор может позвать любого
+func (h *Handler) checkRequested(tx *gorm.DB, authorID string, ids []string) error {
+	if len(ids) > h.reviewers {
+		return &reviewerError{http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("at most %d reviewers", h.reviewers)}
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id == "" || id == authorID || seen[id] {
+			return &reviewerError{http.StatusBadRequest, "BAD_REQUEST",
+				"requested reviewers must be distinct, non-empty and not the author"}
+		}
+		seen[id] = true
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	var users []model.UserDB
+	if err := tx.Where("user_id IN ?", ids).Find(&users).Error; err != nil {
+		return err
+	}
+	if len(users) != len(ids) {
+		return &reviewerError{http.StatusNotFound, "NOT_FOUND", "requested reviewer not found"}
+	}
+	rules, err := loadPairRules(tx, authorID)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		switch {
+		case !u.IsActive:
+			return &reviewerError{http.StatusConflict, "REVIEWER_INACTIVE", "requested reviewer " + u.UserID + " is inactive"}
+		case rules[authorID].exclude[u.UserID]:
+			return &reviewerError{http.StatusConflict, "REVIEWER_EXCLUDED", "requested reviewer " + u.UserID + " is excluded by rule"}
+		}
+	}
+	return nil
+}
+
+// PRSetReviewers обрабатывает POST /pullRequest/setReviewers
+// POST /pullRequest/setReviewers { pull_request_id, reviewers:[...] } -> 200 {pr:{...}} + ETag
+// 400 | 403 | 404 NOT_FOUND | 409 PR_MERGED | REVIEWER_INACTIVE | REVIEWER_EXCLUDED | 412 PRECONDITION_FAILED (If-Match)
+//
+// reviewers — полный список закреплённых (pinned) ревьюверов, они занимают первые слоты.
+// Остальные слоты сохраняют текущих незакреплённых ревьюверов, а пустые заполняются
+// автоматически из команды автора. Прежние закреплённые, не попавшие в список, снимаются
+func (h *Handler) PRSetReviewers(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	var in struct {
+		PRID      string   `json:"pull_request_id"`
+		Reviewers []string `json:"reviewers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.PRID == "" {
+		writeErr(w, "BAD_REQUEST", "pull_request_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var out PullRequest
+	err := db.Transaction(func(tx *gorm.DB) error {
+		pr, err := lockPR(tx, in.PRID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				writeErr(w, "NOT_FOUND", "PR not found", http.StatusNotFound)
+				return errStop
+			}
+			return err
+		}
+		if !h.allowUserTeam(w, r, tx, pr.AuthorID) {
+			return errStop
+		}
+		if pr.Status == "MERGED" {
+			writeErr(w, "PR_MERGED", "cannot change reviewers on merged PR", http.StatusConflict)
+			return errStop
+		}
+		if !ifMatch(r, pr.Version) {
+			writePreconditionFailed(w, pr.Version)
+			return errStop
+		}
+		if err := h.checkRequested(tx, pr.AuthorID, in.Reviewers); err != nil {
+			var re *reviewerError
+			if errors.As(err, &re) {
+				re.write(w)
+				return errStop
+			}
+			return err
+		}
+
+		var cur []model.PRReviewerDB
+		if err := tx.Where("pr_id = ?", pr.ID).Order("position").Find(&cur).Error; err != nil {
+			return err
+		}
+		// новый состав: закреплённые, затем текущие незакреплённые, затем автоматический выбор
+		next := append([]string(nil), in.Reviewers...)
+		taken := map[string]bool{}
+		for _, id := range next {
+			taken[id] = true
+		}
+		for _, s := range cur {
+			if len(next) < h.reviewers && !s.Pinned && !taken[s.ReviewerID] {
+				next = append(next, s.ReviewerID)
+				taken[s.ReviewerID] = true
+			}
+		}
+		if len(next) < h.reviewers {
+			var author model.UserDB
+			if err := tx.First(&author, "user_id = ?", pr.AuthorID).Error; err != nil {
+				return err
+			}
+			declined, err := declinedUsers(tx, pr.ID)
+			if err != nil {
+				return err
+			}
+			if next, err = h.pickReviewers(tx, h.strategy(r), author, next, declined); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("pr_id = ?", pr.ID).Delete(&model.PRReviewerDB{}).Error; err != nil {
+			return err
+		}
+		for i, id := range next {
+			slot := model.PRReviewerDB{PRID: pr.ID, ReviewerID: id, Position: int16(i + 1), Pinned: i < len(in.Reviewers)}
+			if err := tx.Create(&slot).Error; err != nil {
+				return err
+			}
+		}
+		if err := recordSetReviewers(tx, pr.ID, cur, next); err != nil {
+			return err
+		}
+		if err := bumpVersion(tx, pr.ID); err != nil {
+			return err
+		}
+		pr.Version++
+		out, err = h.buildPR(tx, pr)
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, errStop) {
+			h.dbErr(w, r, err)
+		}
+		return
+	}
+	w.Header().Set("ETag", prETag(out.Version))
+	writeJSON(w, http.StatusOK, map[string]any{"pr": out})
+}
+
+// recordSetReviewers пишет в историю разницу составов: снятый и добавленный ревьюверы
+// образуют пару REASSIGNED, лишние снятые — UNASSIGNED, лишние добавленные — ASSIGNED
+func recordSetReviewers(tx *gorm.DB, prID string, cur []model.PRReviewerDB, next []string) error {
+	inNext := make(map[string]bool, len(next))
+	for _, id := range next {
+		inNext[id] = true
+	}
+	inCur := make(map[string]bool, len(cur))
+	var removed, added []string
+	for _, s := range cur {
+		inCur[s.ReviewerID] = true
+		if !inNext[s.ReviewerID] {
+			removed = append(removed, s.ReviewerID)
+		}
+	}
+	for _, id := range next {
+		if !inCur[id] {
+			added = append(added, id)
+		}
+	}
+	for len(removed) > 0 || len(added) > 0 {
+		var kind, oldID, newID string
+		switch {
+		case len(removed) > 0 && len(added) > 0:
+			kind, oldID, newID = model.EventReassigned, removed[0], added[0]
+			removed, added = removed[1:], added[1:]
+		case len(removed) > 0:
+			kind, oldID = model.EventUnassigned, removed[0]
+			removed = removed[1:]
+		default:
+			kind, newID = model.EventAssigned, added[0]
+			added = added[1:]
+		}
+		if err := recordEvent(tx, prID, kind, oldID, newID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
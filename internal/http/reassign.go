@@ -0,0 +1,101 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+	"github.com/alinaaved/pr-reviewer/internal/selector"
+)
+
+// errNoCandidate — некого поставить на место ревьювера
+var errNoCandidate = errors.New("no replacement candidate")
+
+// errAtCapacity — кандидаты есть, но все достигли лимита открытых ревью; частный случай errNoCandidate
+var errAtCapacity = fmt.Errorf("%w: all candidates are at capacity", errNoCandidate)
+
+// replaceReviewer ставит в слот slot активного участника команды team —
+// не автора PR, не текущих ревьюверов, не отказавшихся от этого PR, не исключённых правилами —
+// и возвращает его user_id. Если уходит последний обязательный ревьювер (правило REQUIRE),
+// замена сначала ищется среди обязательных; требование команды автора к числу senior сохраняется.
+// reason — причина отказа ревьювера для истории (пустая, если это не отказ).
+// Кандидата выбирает sel. Нет подходящих — errNoCandidate; есть, но все на пределе max_open_reviews — errAtCapacity
+func (h *Handler) replaceReviewer(tx *gorm.DB, sel selector.Strategy, pr model.PullRequestDB, slot model.PRReviewerDB, team, reason string) (string, error) {
+	var other []string
+	if err := tx.Table("pr_reviewers").
+		Select("reviewer_id").
+		Where("pr_id = ? AND position <> ?", pr.ID, slot.Position).
+		Scan(&other).Error; err != nil {
+		return "", err
+	}
+
+	declined, err := declinedUsers(tx, pr.ID)
+	if err != nil {
+		return "", err
+	}
+
+	exclude := append(append(other, declined...), slot.ReviewerID, pr.AuthorID)
+	rules, err := loadPairRules(tx, pr.AuthorID)
+	if err != nil {
+		return "", err
+	}
+	// состав команды автора: если уходит senior и без него их не хватает — замена из senior
+	comp, err := composition(tx, pr.AuthorID)
+	if err != nil {
+		return "", err
+	}
+	have, err := countLevel(tx, other, comp.Level)
+	if err != nil {
+		return "", err
+	}
+	var picked []selector.Candidate
+	found := false // были ли кандидаты до учёта лимитов
+	if p := rules[pr.AuthorID]; p.require[slot.ReviewerID] && !p.hasRequired(other) {
+		cands, err := loadCandidatesIn(tx, p.required(), exclude)
+		if err != nil {
+			return "", err
+		}
+		found = len(cands) > 0
+		picked = selector.PickComposed(sel, cands, 1, comp, have)
+	}
+	if len(picked) == 0 {
+		cands, err := loadCandidates(tx, team, pr.AuthorID, exclude)
+		if err != nil {
+			return "", err
+		}
+		found = found || len(cands) > 0
+		picked = selector.PickComposed(sel, cands, 1, comp, have)
+	}
+	switch {
+	case len(picked) == 0 && found:
+		return "", errAtCapacity
+	case len(picked) == 0:
+		return "", errNoCandidate
+	}
+	newID := picked[0].UserID
+
+	// апдейтим конкретный слот по position (важно!); выбранный автоматически не закреплён
+	if err := tx.Model(&model.PRReviewerDB{}).
+		Where("pr_id = ? AND position = ?", pr.ID, slot.Position).
+		Updates(map[string]any{"reviewer_id": newID, "pinned": false}).Error; err != nil {
+		return "", err
+	}
+	if err := bumpVersion(tx, pr.ID); err != nil {
+		return "", err
+	}
+	if err := recordEventReason(tx, pr.ID, model.EventReassigned, slot.ReviewerID, newID, reason); err != nil {
+		return "", err
+	}
+	return newID, nil
+}
+
+// declinedUsers — кто отказывался от ревью этого PR; обратно их не назначаем
+func declinedUsers(tx *gorm.DB, prID string) ([]string, error) {
+	var out []string
+	err := tx.Model(&model.PREventDB{}).
+		Where("pr_id = ? AND reason IS NOT NULL", prID).
+		Distinct().Pluck("old_user_id", &out).Error
+	return out, err
+}
@@ -0,0 +1,65 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reviewQuery — разобранные параметры GET /users/getReview
+type reviewQuery struct {
+	UserID string
+	Status string // OPEN | MERGED | ALL
+	Limit  int
+	After  *listCursor
+	Age    bool
+}
+
+func parseReviewQuery(q url.Values) (reviewQuery, error) {
+	out := reviewQuery{
+		UserID: q.Get("user_id"),
+		Status: strings.ToUpper(q.Get("status")),
+		Limit:  defaultListLimit,
+	}
+	if out.UserID == "" {
+		return out, errors.New("user_id is required")
+	}
+	switch out.Status {
+	case "":
+		out.Status = "OPEN"
+	case "OPEN", "MERGED", "ALL":
+	default:
+		return out, errors.New("status must be OPEN, MERGED or ALL")
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListLimit {
+			return out, fmt.Errorf("limit must be in 1..%d", maxListLimit)
+		}
+		out.Limit = n
+	}
+	if v := q.Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil {
+			return out, err
+		}
+		if _, err := time.Parse(time.RFC3339Nano, c.Value); err != nil {
+			return out, errors.New("invalid cursor")
+		}
+		out.After = c
+	}
+	if v := q.Get("include"); v != "" {
+		for _, f := range strings.Split(v, ",") {
+			switch strings.TrimSpace(f) {
+			case "age":
+				out.Age = true
+			default:
+				return out, fmt.Errorf("unknown include %q (want age)", f)
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,280 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+	"github.com/alinaaved/pr-reviewer/internal/selector"
+)
+
+// TeamSync обрабатывает POST /team/sync
+// POST /team/sync {teams:[...], dry_run} -> 200 {dry_run, diff:{...}} | 400 | 403
+//
+// Ростер — полный желаемый состав: недостающие команды создаются, пользователи
+// создаются/переводятся/обновляются, а активные пользователи, которых нет
+// в ростере, деактивируются. Открытые ревью деактивированных переназначаются
+// на активных из их команды; если замены нет — ревьювер снимается с PR.
+// С dry_run=true ничего не меняется, возвращается только diff
+func (h *Handler) TeamSync(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	var in RosterSyncRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeErr(w, "BAD_REQUEST", "invalid json", http.StatusBadRequest)
+		return
+	}
+	if err := h.validateRoster(in.Teams); err != nil {
+		writeErr(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	// ростер затрагивает все команды — токенам, ограниченным командой, нельзя
+	if !h.allowTeam(w, r, "") {
+		return
+	}
+
+	var diff RosterDiff
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		diff, err = h.syncRoster(tx, h.strategy(r), in.Teams, in.DryRun)
+		if err != nil {
+			return err
+		}
+		if in.DryRun {
+			return errDryRun // откатываем транзакцию
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		h.dbErr(w, r, err)
+		return
+	}
+	// метрики — только для закоммиченных переназначений, dry-run откатан
+	if !in.DryRun {
+		for _, ra := range diff.Reassignments {
+			if ra.Unassigned {
+				h.metrics.NoCandidate("sync")
+			} else {
+				h.metrics.Reassigned()
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"dry_run": in.DryRun, "diff": diff})
+}
+
+var errDryRun = errors.New("dry run")
+
+// validateRoster проверяет имена команд, состав и лимиты команд и участников,
+// а также что пользователь указан в ростере один раз
+func (h *Handler) validateRoster(teams []Team) error {
+	seenTeam := map[string]bool{}
+	seenUser := map[string]string{}
+	for _, t := range teams {
+		if t.TeamName == "" {
+			return errors.New("team_name is required")
+		}
+		if seenTeam[t.TeamName] {
+			return fmt.Errorf("team %s is listed twice", t.TeamName)
+		}
+		seenTeam[t.TeamName] = true
+		if err := h.validateComposition(t); err != nil {
+			return fmt.Errorf("team %s: %w", t.TeamName, err)
+		}
+		for _, m := range t.Members {
+			if m.UserID == "" {
+				return fmt.Errorf("team %s: user_id is required", t.TeamName)
+			}
+			if prev, ok := seenUser[m.UserID]; ok {
+				return fmt.Errorf("user %s is listed in teams %s and %s", m.UserID, prev, t.TeamName)
+			}
+			seenUser[m.UserID] = t.TeamName
+		}
+	}
+	return nil
+}
+
+// syncRoster приводит БД к ростеру внутри tx и возвращает diff.
+// В dry-run запись тоже идёт (её откатит вызывающий), кроме переназначений:
+// для них выбор случаен, поэтому в diff попадает только список ревью
+func (h *Handler) syncRoster(tx *gorm.DB, sel selector.Strategy, teams []Team, dryRun bool) (RosterDiff, error) {
+	diff := RosterDiff{
+		TeamsCreated: []string{}, UsersCreated: []string{}, UsersMoved: []UserMove{},
+		UsersRenamed: []string{}, UsersActivated: []string{}, UsersDeactivated: []string{},
+		UsersLeveled: []string{}, TeamsUpdated: []string{}, Reassignments: []RosterReassignment{},
+	}
+
+	var existingTeams []model.TeamDB
+	if err := tx.Find(&existingTeams).Error; err != nil {
+		return diff, err
+	}
+	curTeams := map[string]model.TeamDB{}
+	for _, t := range existingTeams {
+		curTeams[t.TeamName] = t
+	}
+	var existingUsers []model.UserDB
+	if err := tx.Order("user_id").Find(&existingUsers).Error; err != nil {
+		return diff, err
+	}
+	users := map[string]model.UserDB{}
+	for _, u := range existingUsers {
+		users[u.UserID] = u
+	}
+
+	// 1) команды и участники из ростера
+	listed := map[string]bool{}
+	for _, t := range teams {
+		wantTeam := model.TeamDB{TeamName: t.TeamName, MinSeniorReviewers: int16(t.MinSeniorReviewers), MaxOpenReviews: t.MaxOpenReviews}
+		curTeam, ok := curTeams[t.TeamName]
+		if !ok {
+			if err := tx.Create(&wantTeam).Error; err != nil {
+				return diff, err
+			}
+			diff.TeamsCreated = append(diff.TeamsCreated, t.TeamName)
+		} else {
+			// не указанные в ростере min_senior_reviewers (0) и max_open_reviews не меняем
+			if t.MinSeniorReviewers == 0 {
+				wantTeam.MinSeniorReviewers = curTeam.MinSeniorReviewers
+			}
+			if wantTeam.MaxOpenReviews == nil || sameLimit(wantTeam.MaxOpenReviews, curTeam.MaxOpenReviews) {
+				wantTeam.MaxOpenReviews = curTeam.MaxOpenReviews
+			}
+			if wantTeam.MinSeniorReviewers != curTeam.MinSeniorReviewers || !sameLimit(wantTeam.MaxOpenReviews, curTeam.MaxOpenReviews) {
+				if err := tx.Model(&model.TeamDB{}).Where("team_name = ?", t.TeamName).
+					Updates(map[string]any{"min_senior_reviewers": wantTeam.MinSeniorReviewers, "max_open_reviews": wantTeam.MaxOpenReviews}).
+					Error; err != nil {
+					return diff, err
+				}
+				diff.TeamsUpdated = append(diff.TeamsUpdated, t.TeamName)
+			}
+		}
+		for _, m := range t.Members {
+			listed[m.UserID] = true
+			want := model.UserDB{UserID: m.UserID, Username: m.Username, IsActive: m.Active(), TeamName: t.TeamName,
+				Level: m.Level, MaxOpenReviews: m.MaxOpenReviews}
+			cur, ok := users[m.UserID]
+			if ok && want.Level == "" {
+				want.Level = cur.Level // уровень не указан — не меняем
+			}
+			if ok && (want.MaxOpenReviews == nil || sameLimit(want.MaxOpenReviews, cur.MaxOpenReviews)) {
+				want.MaxOpenReviews = cur.MaxOpenReviews // лимит не указан или тот же — не меняем
+			}
+			if !ok {
+				if err := tx.Create(&want).Error; err != nil {
+					return diff, err
+				}
+				if err := recordActivity(tx, m.UserID, want.IsActive); err != nil {
+					return diff, err
+				}
+				diff.UsersCreated = append(diff.UsersCreated, m.UserID)
+				continue
+			}
+			if cur == want {
+				continue
+			}
+			if cur.TeamName != want.TeamName {
+				diff.UsersMoved = append(diff.UsersMoved, UserMove{UserID: m.UserID, From: cur.TeamName, To: want.TeamName})
+			}
+			if cur.Username != want.Username {
+				diff.UsersRenamed = append(diff.UsersRenamed, m.UserID)
+			}
+			if cur.Level != want.Level {
+				diff.UsersLeveled = append(diff.UsersLeveled, m.UserID)
+			}
+			if cur.IsActive != want.IsActive {
+				if err := recordActivity(tx, m.UserID, want.IsActive); err != nil {
+					return diff, err
+				}
+				if want.IsActive {
+					diff.UsersActivated = append(diff.UsersActivated, m.UserID)
+				} else {
+					diff.UsersDeactivated = append(diff.UsersDeactivated, m.UserID)
+				}
+			}
+			if err := tx.Model(&model.UserDB{}).Where("user_id = ?", m.UserID).
+				Updates(map[string]any{"username": want.Username, "is_active": want.IsActive, "team_name": want.TeamName, "level": want.Level,
+					"max_open_reviews": want.MaxOpenReviews}).
+				Error; err != nil {
+				return diff, err
+			}
+		}
+	}
+
+	// 2) активные пользователи вне ростера — деактивируем
+	for _, u := range existingUsers {
+		if listed[u.UserID] || !u.IsActive {
+			continue
+		}
+		if err := tx.Model(&model.UserDB{}).Where("user_id = ?", u.UserID).Update("is_active", false).Error; err != nil {
+			return diff, err
+		}
+		if err := recordActivity(tx, u.UserID, false); err != nil {
+			return diff, err
+		}
+		diff.UsersDeactivated = append(diff.UsersDeactivated, u.UserID)
+	}
+	sort.Strings(diff.UsersDeactivated)
+
+	// 3) открытые ревью деактивированных; закреплённые вручную (pinned) не трогаем
+	if len(diff.UsersDeactivated) == 0 {
+		return diff, nil
+	}
+	var slots []model.PRReviewerDB
+	if err := tx.Table("pr_reviewers AS r").
+		Select("r.*").
+		Joins("JOIN pull_requests pr ON pr.pull_request_id = r.pr_id").
+		Where("pr.status = 'OPEN' AND NOT r.pinned AND r.reviewer_id IN ?", diff.UsersDeactivated).
+		Order("r.pr_id, r.position").
+		Scan(&slots).Error; err != nil {
+		return diff, err
+	}
+	for _, slot := range slots {
+		ra := RosterReassignment{PullRequestID: slot.PRID, OldUserID: slot.ReviewerID}
+		if dryRun {
+			diff.Reassignments = append(diff.Reassignments, ra)
+			continue
+		}
+		// слоты прочитаны до блокировки PR: за это время его могли смерджить или переназначить
+		pr, err := lockPR(tx, slot.PRID)
+		if err != nil {
+			return diff, err
+		}
+		var cur int64
+		if err := tx.Model(&model.PRReviewerDB{}).
+			Where("pr_id = ? AND position = ? AND reviewer_id = ?", slot.PRID, slot.Position, slot.ReviewerID).
+			Count(&cur).Error; err != nil {
+			return diff, err
+		}
+		if pr.Status != "OPEN" || cur == 0 {
+			continue
+		}
+		var old model.UserDB
+		if err := tx.First(&old, "user_id = ?", slot.ReviewerID).Error; err != nil {
+			return diff, err
+		}
+		newID, err := h.replaceReviewer(tx, sel, pr, slot, old.TeamName, "")
+		switch {
+		case errors.Is(err, errNoCandidate):
+			if err := tx.Where("pr_id = ? AND position = ?", slot.PRID, slot.Position).
+				Delete(&model.PRReviewerDB{}).Error; err != nil {
+				return diff, err
+			}
+			if err := recordUnfilled(tx, slot.PRID, slot.ReviewerID, ""); err != nil {
+				return diff, err
+			}
+			if err := bumpVersion(tx, slot.PRID); err != nil {
+				return diff, err
+			}
+			ra.Unassigned = true
+		case err != nil:
+			return diff, err
+		default:
+			ra.NewUserID = newID
+		}
+		diff.Reassignments = append(diff.Reassignments, ra)
+	}
+	return diff, nil
+}
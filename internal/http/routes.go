@@ -0,0 +1,53 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/alinaaved/pr-reviewer/internal/tracing"
+)
+
+// Routes собирает роутер со всеми маршрутами API
+func (h *Handler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(RequestID, tracing.Middleware, h.LogRequests, h.metrics.Middleware, h.Recover)
+	r.Get("/healthz", h.Healthz)
+	r.Get("/readyz", h.Readyz)
+	if h.metrics != nil {
+		r.Handle("/metrics", h.metrics.Handler())
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.Authenticate, h.SelectorSeed)
+
+		r.Post("/team/add", h.TeamAdd)
+		r.Get("/team/get", h.TeamGet)
+		r.Post("/team/sync", h.TeamSync)
+		r.Post("/team/setComposition", h.TeamSetComposition)
+		r.Post("/team/setCapacity", h.TeamSetCapacity)
+		r.Post("/users/setIsActive", h.UsersSetIsActive)
+		r.Post("/users/setLevel", h.UsersSetLevel)
+		r.Post("/users/setCapacity", h.UsersSetCapacity)
+		r.Get("/users/getReview", h.UsersGetReview)
+		r.With(h.Idempotent).Post("/pullRequest/create", h.PRCreate)
+		r.With(h.Idempotent).Post("/pullRequest/createBatch", h.PRCreateBatch)
+		r.Post("/pullRequest/merge", h.PRMerge)
+		r.Get("/pullRequest/get", h.PRGet)
+		r.Get("/pullRequest/list", h.PRList)
+		r.With(h.Idempotent).Post("/pullRequest/reassign", h.PRReassign)
+		r.With(h.Idempotent).Post("/pullRequest/decline", h.PRDecline)
+		r.With(h.Idempotent).Post("/pullRequest/setReviewers", h.PRSetReviewers)
+		r.With(h.Idempotent).Post("/pullRequest/import", h.PRImport)
+		r.Post("/rules/add", h.RulesAdd)
+		r.Get("/rules/list", h.RulesList)
+		r.Post("/rules/delete", h.RulesDelete)
+		r.Get("/stats/assignments-by-user", h.StatsAssignmentsByUser)
+		r.Get("/stats/reviewers", h.StatsReviewers)
+		r.Get("/stats/authors", h.StatsAuthors)
+		r.Get("/stats/summary", h.StatsSummary)
+		r.Get("/stats/fairness", h.StatsFairness)
+		r.Get("/export/{dataset}", h.Export)
+	})
+	return r
+}
@@ -0,0 +1,142 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+// ruleKinds — допустимые виды правил назначения
+var ruleKinds = map[string]bool{model.RuleExclude: true, model.RuleRequire: true}
+
+func toRule(x model.ReviewerRuleDB) ReviewerRule {
+	out := ReviewerRule{ID: x.ID, Kind: x.Kind, AuthorID: x.AuthorID, ReviewerID: x.ReviewerID, CreatedAt: x.CreatedAt}
+	if x.TeamName != nil {
+		out.TeamName = *x.TeamName
+	}
+	return out
+}
+
+// RulesAdd обрабатывает POST /rules/add
+// POST /rules/add { kind, author_id, reviewer_id, team_name? } -> 201 {rule:{...}}
+// 400 | 403 | 404 NOT_FOUND | 409 RULE_EXISTS (у пары уже есть правило)
+//
+// Правило с team_name действует, пока автор в этой команде, и меняется токеном команды;
+// глобальное (без team_name) — только глобальным writer/admin
+func (h *Handler) RulesAdd(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	var in ReviewerRule
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeErr(w, "BAD_REQUEST", "invalid json", http.StatusBadRequest)
+		return
+	}
+	switch {
+	case !ruleKinds[in.Kind]:
+		writeErr(w, "BAD_REQUEST", "kind must be EXCLUDE or REQUIRE", http.StatusBadRequest)
+		return
+	case in.AuthorID == "" || in.ReviewerID == "" || in.AuthorID == in.ReviewerID:
+		writeErr(w, "BAD_REQUEST", "author_id and reviewer_id are required and must differ", http.StatusBadRequest)
+		return
+	}
+	if !h.allowTeam(w, r, in.TeamName) {
+		return
+	}
+
+	var users []model.UserDB
+	if err := db.Where("user_id IN ?", []string{in.AuthorID, in.ReviewerID}).Find(&users).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	if len(users) != 2 {
+		writeErr(w, "NOT_FOUND", "author or reviewer not found", http.StatusNotFound)
+		return
+	}
+	if in.TeamName != "" {
+		for _, u := range users {
+			if u.UserID == in.AuthorID && u.TeamName != in.TeamName {
+				writeErr(w, "BAD_REQUEST", "author is not a member of team_name", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	var cnt int64
+	if err := db.Model(&model.ReviewerRuleDB{}).
+		Where("author_id = ? AND reviewer_id = ?", in.AuthorID, in.ReviewerID).
+		Count(&cnt).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	if cnt > 0 {
+		writeErr(w, "RULE_EXISTS", "rule for this author and reviewer already exists", http.StatusConflict)
+		return
+	}
+
+	rule := model.ReviewerRuleDB{Kind: in.Kind, AuthorID: in.AuthorID, ReviewerID: in.ReviewerID}
+	if in.TeamName != "" {
+		rule.TeamName = &in.TeamName
+	}
+	if err := db.Create(&rule).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"rule": toRule(rule)})
+}
+
+// RulesList обрабатывает GET /rules/list
+// GET /rules/list?author_id=&team_name= -> 200 {rules:[...]}
+// team_name отбирает правила этой команды; глобальные видны всегда
+func (h *Handler) RulesList(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	q := db.Order("id")
+	if v := r.URL.Query().Get("author_id"); v != "" {
+		q = q.Where("author_id = ?", v)
+	}
+	if v := r.URL.Query().Get("team_name"); v != "" {
+		q = q.Where("team_name IS NULL OR team_name = ?", v)
+	}
+	var rows []model.ReviewerRuleDB
+	if err := q.Find(&rows).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	out := make([]ReviewerRule, 0, len(rows))
+	for _, x := range rows {
+		out = append(out, toRule(x))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"rules": out})
+}
+
+// RulesDelete обрабатывает POST /rules/delete
+// POST /rules/delete { id } -> 200 {rule:{...}} | 403 | 404 NOT_FOUND
+func (h *Handler) RulesDelete(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	var in struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.ID == 0 {
+		writeErr(w, "BAD_REQUEST", "id is required", http.StatusBadRequest)
+		return
+	}
+	var rule model.ReviewerRuleDB
+	if err := db.First(&rule, "id = ?", in.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeErr(w, "NOT_FOUND", "rule not found", http.StatusNotFound)
+			return
+		}
+		h.dbErr(w, r, err)
+		return
+	}
+	if !h.allowTeam(w, r, toRule(rule).TeamName) {
+		return
+	}
+	if err := db.Delete(&model.ReviewerRuleDB{}, "id = ?", rule.ID).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"rule": toRule(rule)})
+}
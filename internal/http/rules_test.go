@@ -0,0 +1,29 @@
+package httpapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRules_BadRequest(t *testing.T) {
+	h := dryRunHandler(t)
+	for _, body := range []string{
+		`{`,
+		`{"kind":"NEVER","author_id":"u1","reviewer_id":"u2"}`,
+		`{"kind":"EXCLUDE","author_id":"u1"}`,
+		`{"kind":"REQUIRE","author_id":"u1","reviewer_id":"u1"}`,
+	} {
+		rec := httptest.NewRecorder()
+		h.RulesAdd(rec, httptest.NewRequest(http.MethodPost, "/rules/add", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status=%d (want 400)", body, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.RulesDelete(rec, httptest.NewRequest(http.MethodPost, "/rules/delete", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("delete without id: status=%d (want 400)", rec.Code)
+	}
+}
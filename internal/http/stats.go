@@ -0,0 +1,257 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// defaultStatsWindow — окно статистики по умолчанию (спринт)
+const defaultStatsWindow = 14 * 24 * time.Hour
+
+// statsQuery — окно [From, To) и необязательная команда
+type statsQuery struct {
+	From time.Time
+	To   time.Time
+	Team string
+}
+
+func (q statsQuery) args() map[string]any {
+	return map[string]any{"from": q.From, "to": q.To, "team": q.Team}
+}
+
+// parseStatsQuery разбирает from/to (RFC3339) и team_name.
+// Без to — сейчас, без from — to минус 14 дней
+func parseStatsQuery(q url.Values) (statsQuery, error) {
+	out := statsQuery{To: time.Now().UTC(), Team: q.Get("team_name")}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"to", &out.To}, {"from", &out.From}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return out, fmt.Errorf("%s must be RFC3339", p.name)
+		}
+		*p.dst = t
+	}
+	if out.From.IsZero() {
+		out.From = out.To.Add(-defaultStatsWindow)
+	}
+	if !out.From.Before(out.To) {
+		return out, errors.New("from must be before to")
+	}
+	return out, nil
+}
+
+// StatsReviewers обрабатывает GET /stats/reviewers
+// GET /stats/reviewers?from=&to=&team_name= -> 200 {from, to, team_name, items:[...]} | 400
+//
+// Назначения и переназначения считаются по истории (pr_events) за окно,
+// open_load — текущая нагрузка открытыми ревью. В reassigned_out входят замены
+// и снятия без замены (отказ, ростер), но не ручное снятие через setReviewers
+func (h *Handler) StatsReviewers(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	in, err := parseStatsQuery(r.URL.Query())
+	if err != nil {
+		writeErr(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	items := []ReviewerStats{}
+	if err := db.Raw(`
+		SELECT u.user_id, u.team_name, u.is_active,
+			COUNT(*) FILTER (WHERE e.kind IN ('ASSIGNED', 'REASSIGNED') AND e.new_user_id = u.user_id) AS assigned,
+			COUNT(*) FILTER (WHERE e.kind = 'REASSIGNED' AND e.new_user_id = u.user_id) AS reassigned_in,
+			COUNT(*) FILTER (WHERE e.old_user_id = u.user_id AND (e.kind = 'REASSIGNED'
+				OR e.kind = 'UNASSIGNED' AND EXISTS (SELECT 1 FROM pr_events n
+					WHERE n.pr_id = e.pr_id AND n.kind = 'NO_CANDIDATE' AND n.created_at = e.created_at)))
+				AS reassigned_out,
+			COUNT(*) FILTER (WHERE e.reason IS NOT NULL AND e.old_user_id = u.user_id) AS declined,
+			(SELECT COUNT(*) FROM pr_reviewers r
+				JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+				WHERE r.reviewer_id = u.user_id AND pr.status = 'OPEN') AS open_load
+		FROM users u
+		LEFT JOIN pr_events e ON (e.new_user_id = u.user_id OR e.old_user_id = u.user_id)
+			AND e.created_at >= @from AND e.created_at < @to
+		WHERE @team = '' OR u.team_name = @team
+		GROUP BY u.user_id, u.team_name, u.is_active
+		ORDER BY assigned DESC, u.user_id`, in.args()).
+		Scan(&items).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"from": in.From, "to": in.To, "team_name": in.Team, "items": items,
+	})
+}
+
+// StatsAuthors обрабатывает GET /stats/authors
+// GET /stats/authors?from=&to=&team_name= -> 200 {from, to, team_name, items:[...]} | 400
+//
+// В выдаче только авторы, у которых за окно есть созданные или смердженные PR
+func (h *Handler) StatsAuthors(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	in, err := parseStatsQuery(r.URL.Query())
+	if err != nil {
+		writeErr(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	var rows []struct {
+		UserID     string
+		TeamName   string
+		PrsCreated int64
+		PrsMerged  int64
+		PrsOpen    int64
+		Median     *float64 `gorm:"column:median_time_to_merge_seconds"`
+	}
+	if err := db.Raw(`
+		SELECT u.user_id, u.team_name,
+			COUNT(*) FILTER (WHERE pr.created_at >= @from AND pr.created_at < @to) AS prs_created,
+			COUNT(*) FILTER (WHERE pr.merged_at >= @from AND pr.merged_at < @to) AS prs_merged,
+			COUNT(*) FILTER (WHERE pr.status = 'OPEN') AS prs_open,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM pr.merged_at - pr.created_at))
+				FILTER (WHERE pr.merged_at >= @from AND pr.merged_at < @to) AS median_time_to_merge_seconds
+		FROM users u
+		JOIN pull_requests pr ON pr.author_id = u.user_id
+		WHERE @team = '' OR u.team_name = @team
+		GROUP BY u.user_id, u.team_name
+		HAVING COUNT(*) FILTER (WHERE (pr.created_at >= @from AND pr.created_at < @to)
+			OR (pr.merged_at >= @from AND pr.merged_at < @to)) > 0
+		ORDER BY prs_created DESC, u.user_id`, in.args()).
+		Scan(&rows).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	items := make([]AuthorStats, 0, len(rows))
+	for _, x := range rows {
+		items = append(items, AuthorStats{
+			UserID:                   x.UserID,
+			TeamName:                 x.TeamName,
+			PRsCreated:               x.PrsCreated,
+			PRsMerged:                x.PrsMerged,
+			PRsOpen:                  x.PrsOpen,
+			MedianTimeToMergeSeconds: x.Median,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"from": in.From, "to": in.To, "team_name": in.Team, "items": items,
+	})
+}
+
+// StatsSummary обрабатывает GET /stats/summary
+// GET /stats/summary?from=&to=&team_name= -> 200 {...} | 400
+//
+// Команда — команда автора PR. no_candidate_rate — доля попыток заполнить
+// слот ревьювера (назначение, переназначение), для которых не нашлось кандидата.
+// Time-to-first-review — от первого назначения до первой реакции на PR:
+// отказа ревьювера или merge (отдельного approve в сервисе нет)
+func (h *Handler) StatsSummary(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	in, err := parseStatsQuery(r.URL.Query())
+	if err != nil {
+		writeErr(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	var prs struct {
+		PrsCreated int64
+		PrsMerged  int64
+		PrsOpen    int64
+		Median     *float64 `gorm:"column:median_time_to_merge_seconds"`
+	}
+	if err := db.Raw(`
+		SELECT
+			COUNT(*) FILTER (WHERE pr.created_at >= @from AND pr.created_at < @to) AS prs_created,
+			COUNT(*) FILTER (WHERE pr.merged_at >= @from AND pr.merged_at < @to) AS prs_merged,
+			COUNT(*) FILTER (WHERE pr.status = 'OPEN') AS prs_open,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM pr.merged_at - pr.created_at))
+				FILTER (WHERE pr.merged_at >= @from AND pr.merged_at < @to) AS median_time_to_merge_seconds
+		FROM pull_requests pr
+		JOIN users a ON a.user_id = pr.author_id
+		WHERE @team = '' OR a.team_name = @team`, in.args()).
+		Scan(&prs).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	var ev struct {
+		Assignments   int64
+		Reassignments int64
+		NoCandidate   int64
+	}
+	if err := db.Raw(`
+		SELECT
+			COUNT(*) FILTER (WHERE e.kind IN ('ASSIGNED', 'REASSIGNED')) AS assignments,
+			COUNT(*) FILTER (WHERE e.kind = 'REASSIGNED') AS reassignments,
+			COUNT(*) FILTER (WHERE e.kind = 'NO_CANDIDATE') AS no_candidate
+		FROM pr_events e
+		JOIN pull_requests pr ON pr.pull_request_id = e.pr_id
+		JOIN users a ON a.user_id = pr.author_id
+		WHERE e.created_at >= @from AND e.created_at < @to
+			AND (@team = '' OR a.team_name = @team)`, in.args()).
+		Scan(&ev).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	var firstReview struct {
+		Median *float64 `gorm:"column:median_time_to_first_review_seconds"`
+	}
+	if err := db.Raw(`
+		SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM f.decided_at - f.assigned_at))
+			AS median_time_to_first_review_seconds
+		FROM (
+			SELECT
+				MIN(e.created_at) FILTER (WHERE e.kind = 'ASSIGNED') AS assigned_at,
+				MIN(e.created_at) FILTER (WHERE e.reason IS NOT NULL OR e.kind = 'MERGED') AS decided_at
+			FROM pr_events e
+			JOIN pull_requests pr ON pr.pull_request_id = e.pr_id
+			JOIN users a ON a.user_id = pr.author_id
+			WHERE @team = '' OR a.team_name = @team
+			GROUP BY e.pr_id
+		) f
+		WHERE f.assigned_at IS NOT NULL AND f.decided_at >= @from AND f.decided_at < @to`, in.args()).
+		Scan(&firstReview).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	var declines []struct {
+		Reason string
+		Count  int64
+	}
+	if err := db.Raw(`
+		SELECT e.reason, COUNT(*) AS count
+		FROM pr_events e
+		JOIN pull_requests pr ON pr.pull_request_id = e.pr_id
+		JOIN users a ON a.user_id = pr.author_id
+		WHERE e.reason IS NOT NULL AND e.created_at >= @from AND e.created_at < @to
+			AND (@team = '' OR a.team_name = @team)
+		GROUP BY e.reason`, in.args()).
+		Scan(&declines).Error; err != nil {
+		h.dbErr(w, r, err)
+		return
+	}
+	out := StatsSummary{
+		From:                           in.From,
+		To:                             in.To,
+		TeamName:                       in.Team,
+		PRsCreated:                     prs.PrsCreated,
+		PRsMerged:                      prs.PrsMerged,
+		PRsOpen:                        prs.PrsOpen,
+		MedianTimeToMergeSeconds:       prs.Median,
+		MedianTimeToFirstReviewSeconds: firstReview.Median,
+		Assignments:                    ev.Assignments,
+		Reassignments:                  ev.Reassignments,
+		NoCandidate:                    ev.NoCandidate,
+		DeclinesByReason:               make(map[string]int64, len(declines)),
+	}
+	for _, d := range declines {
+		out.DeclinesByReason[d.Reason] = d.Count
+	}
+	if attempts := out.Assignments + out.NoCandidate; attempts > 0 {
+		out.NoCandidateRate = float64(out.NoCandidate) / float64(attempts)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,68 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/alinaaved/pr-reviewer/internal/model"
+)
+
+// prETag — ETag версии PR: "v<version>"; один формат для изменяющих вызовов и /pullRequest/get
+func prETag(version int64) string {
+	return `"v` + strconv.FormatInt(version, 10) + `"`
+}
+
+// etagVersion достаёт версию PR из сильного ETag "v<version>".
+// Слабые W/"..." не принимаются: If-Match сравнивает строго (RFC 9110, 13.1.1)
+func etagVersion(etag string) (int64, bool) {
+	etag = strings.TrimSpace(etag)
+	if len(etag) < 4 || etag[0] != '"' || etag[1] != 'v' || etag[len(etag)-1] != '"' {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(etag[2:len(etag)-1], 10, 64)
+	return n, err == nil
+}
+
+// ifMatch проверяет заголовок If-Match против текущей версии PR.
+// Нет заголовка или * — совпадает; иначе нужен сильный ETag с той же версией
+func ifMatch(r *http.Request, version int64) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	for _, v := range strings.Split(header, ",") {
+		if strings.TrimSpace(v) == "*" {
+			return true
+		}
+		if n, ok := etagVersion(v); ok && n == version {
+			return true
+		}
+	}
+	return false
+}
+
+// writePreconditionFailed — 412 с текущим ETag, чтобы клиент мог перечитать PR и повторить
+func writePreconditionFailed(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", prETag(version))
+	writeErr(w, "PRECONDITION_FAILED", "PR was modified (If-Match does not match current version)",
+		http.StatusPreconditionFailed)
+}
+
+// lockPR читает PR с блокировкой строки до конца транзакции (SELECT ... FOR UPDATE):
+// конкурентные изменения одного PR выполняются по очереди, а не затирают друг друга
+func lockPR(tx *gorm.DB, id string) (model.PullRequestDB, error) {
+	var pr model.PullRequestDB
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pr, "pull_request_id = ?", id).Error
+	return pr, err
+}
+
+// bumpVersion увеличивает версию PR после изменения ревьюверов
+func bumpVersion(tx *gorm.DB, id string) error {
+	return tx.Model(&model.PullRequestDB{}).
+		Where("pull_request_id = ?", id).
+		Update("version", gorm.Expr("version + 1")).Error
+}
@@ -0,0 +1,53 @@
+// Package httpx — общие обёртки net/http для middleware
+package httpx
+
+import "net/http"
+
+// StatusWriter запоминает код ответа и размер тела
+type StatusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// Wrap оборачивает w в StatusWriter. Если w уже StatusWriter, возвращает его же:
+// все middleware запроса видят один код ответа
+func Wrap(w http.ResponseWriter) *StatusWriter {
+	if sw, ok := w.(*StatusWriter); ok {
+		return sw
+	}
+	return &StatusWriter{ResponseWriter: w}
+}
+
+func (sw *StatusWriter) WriteHeader(code int) {
+	if sw.status == 0 {
+		sw.status = code
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *StatusWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	n, err := sw.ResponseWriter.Write(b)
+	sw.bytes += n
+	return n, err
+}
+
+// Unwrap нужен http.ResponseController (Flush и т.п.)
+func (sw *StatusWriter) Unwrap() http.ResponseWriter { return sw.ResponseWriter }
+
+// Written сообщает, отправлены ли уже заголовки ответа
+func (sw *StatusWriter) Written() bool { return sw.status != 0 }
+
+// Status — код ответа; 200, если обработчик ничего не записал
+func (sw *StatusWriter) Status() int {
+	if sw.status == 0 {
+		return http.StatusOK
+	}
+	return sw.status
+}
+
+// Bytes — сколько байт тела записано
+func (sw *StatusWriter) Bytes() int { return sw.bytes }
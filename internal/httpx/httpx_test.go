@@ -0,0 +1,28 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sw := Wrap(rec)
+	if sw.Written() || sw.Status() != http.StatusOK {
+		t.Fatalf("fresh: written=%v status=%d", sw.Written(), sw.Status())
+	}
+	if Wrap(sw) != sw {
+		t.Fatalf("Wrap of StatusWriter must reuse it")
+	}
+
+	sw.WriteHeader(http.StatusTeapot)
+	sw.WriteHeader(http.StatusInternalServerError) // первый код не перезаписывается
+	_, _ = sw.Write([]byte("tea"))
+	if !sw.Written() || sw.Status() != http.StatusTeapot || sw.Bytes() != 3 || rec.Code != http.StatusTeapot {
+		t.Fatalf("status=%d bytes=%d rec=%d", sw.Status(), sw.Bytes(), rec.Code)
+	}
+	if http.NewResponseController(sw).Flush() != nil {
+		t.Fatalf("flush through Unwrap failed")
+	}
+}
@@ -0,0 +1,167 @@
+// Package metrics содержит Prometheus-метрики сервиса: HTTP, пул БД и доменные
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+
+	"github.com/alinaaved/pr-reviewer/internal/httpx"
+)
+
+const namespace = "pr_reviewer"
+
+// Metrics — набор метрик сервиса. Методы безопасно вызывать на nil
+type Metrics struct {
+	reg *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	prsCreated    prometheus.Counter
+	prsMerged     prometheus.Counter
+	reassignments prometheus.Counter
+	noCandidate   *prometheus.CounterVec
+	declines      *prometheus.CounterVec
+	timeToMerge   prometheus.Histogram
+}
+
+// New создаёт и регистрирует метрики; db нужен для статистики пула
+// и gauge открытых ревью по пользователям
+func New(db *gorm.DB) (*Metrics, error) {
+	m := &Metrics{
+		reg: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "http_requests_total",
+			Help: "HTTP-запросы по маршруту, методу и коду ответа",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "http_request_duration_seconds",
+			Help:    "Латентность HTTP-запросов по маршруту",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		prsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "pull_requests_created_total",
+			Help: "Созданные PR",
+		}),
+		prsMerged: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "pull_requests_merged_total",
+			Help: "PR, переведённые в MERGED",
+		}),
+		reassignments: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "reassignments_total",
+			Help: "Успешные переназначения ревьюверов",
+		}),
+		noCandidate: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "no_candidate_total",
+			Help: "Не нашлось кандидата в ревьюверы (op=create — свободный слот при создании PR)",
+		}, []string{"op"}),
+		declines: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "reviews_declined_total",
+			Help: "Отказы ревьюверов от ревью по причине",
+		}, []string{"reason"}),
+		timeToMerge: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "time_to_merge_seconds",
+			Help: "Время от created_at до merged_at",
+			// от 5 минут до ~2 недель
+			Buckets: prometheus.ExponentialBuckets(300, 3, 9),
+		}),
+	}
+
+	cs := []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration,
+		m.prsCreated, m.prsMerged, m.reassignments, m.noCandidate, m.declines, m.timeToMerge,
+	}
+	if db != nil {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs,
+			collectors.NewDBStatsCollector(sqlDB, "postgres"),
+			newOpenReviewsCollector(db),
+		)
+	}
+	for _, c := range cs {
+		if err := m.reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Handler отдаёт метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.reg, promhttp.HandlerOpts{Registry: m.reg})
+}
+
+// Middleware считает запросы и латентность по шаблону маршрута chi
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := httpx.Wrap(w)
+		next.ServeHTTP(sw, r)
+
+		route := "unmatched"
+		if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+			route = rc.RoutePattern()
+		}
+		m.httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(sw.Status())).Inc()
+		m.httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// PRCreated учитывает созданный PR; free — сколько слотов ревьюверов осталось пустыми
+func (m *Metrics) PRCreated(free int) {
+	if m == nil {
+		return
+	}
+	m.prsCreated.Inc()
+	if free > 0 {
+		m.noCandidate.WithLabelValues("create").Add(float64(free))
+	}
+}
+
+// PRMerged учитывает merge и время от создания до merge
+func (m *Metrics) PRMerged(createdAt, mergedAt time.Time) {
+	if m == nil {
+		return
+	}
+	m.prsMerged.Inc()
+	m.timeToMerge.Observe(mergedAt.Sub(createdAt).Seconds())
+}
+
+// Reassigned учитывает успешное переназначение
+func (m *Metrics) Reassigned() {
+	if m == nil {
+		return
+	}
+	m.reassignments.Inc()
+}
+
+// Declined учитывает отказ ревьювера с причиной reason
+func (m *Metrics) Declined(reason string) {
+	if m == nil {
+		return
+	}
+	m.declines.WithLabelValues(reason).Inc()
+}
+
+// NoCandidate учитывает отказ из-за отсутствия кандидата в операции op
+func (m *Metrics) NoCandidate(op string) {
+	if m == nil {
+		return
+	}
+	m.noCandidate.WithLabelValues(op).Inc()
+}
@@ -0,0 +1,65 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/alinaaved/pr-reviewer/internal/metrics"
+)
+
+func TestMetrics_HTTPAndDomain(t *testing.T) {
+	m, err := metrics.New(nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Get("/team/get", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNotFound) })
+	r.Handle("/metrics", m.Handler())
+
+	for i := 0; i < 2; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/team/get?team_name=x", nil))
+	}
+	now := time.Now()
+	m.PRCreated(1)
+	m.PRMerged(now.Add(-time.Hour), now)
+	m.NoCandidate("reassign")
+	m.Declined("BUSY")
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	out := string(body)
+
+	for _, want := range []string{
+		`pr_reviewer_http_requests_total{method="GET",route="/team/get",status="404"} 2`,
+		`pr_reviewer_pull_requests_created_total 1`,
+		`pr_reviewer_pull_requests_merged_total 1`,
+		`pr_reviewer_no_candidate_total{op="create"} 1`,
+		`pr_reviewer_no_candidate_total{op="reassign"} 1`,
+		`pr_reviewer_reviews_declined_total{reason="BUSY"} 1`,
+		`pr_reviewer_time_to_merge_seconds_sum 3600`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("metrics have no %q", want)
+		}
+	}
+}
+
+func TestMetrics_NilIsNoop(t *testing.T) {
+	var m *metrics.Metrics
+	m.PRCreated(2)
+	m.Reassigned()
+	m.Declined("BUSY")
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	if h := m.Middleware(next); h == nil {
+		t.Fatalf("nil middleware")
+	}
+}
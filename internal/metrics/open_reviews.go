@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+// openReviewsCollector на каждый scrape считает открытые ревью по пользователям
+type openReviewsCollector struct {
+	db   *gorm.DB
+	desc *prometheus.Desc
+	errs prometheus.Counter
+}
+
+func newOpenReviewsCollector(db *gorm.DB) *openReviewsCollector {
+	return &openReviewsCollector{
+		db: db,
+		desc: prometheus.NewDesc(namespace+"_open_reviews",
+			"Открытые PR, где пользователь назначен ревьювером", []string{"user_id"}, nil),
+		errs: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "open_reviews_scrape_errors_total",
+			Help: "Ошибки запроса открытых ревью при scrape",
+		}),
+	}
+}
+
+func (c *openReviewsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+	c.errs.Describe(ch)
+}
+
+func (c *openReviewsCollector) Collect(ch chan<- prometheus.Metric) {
+	defer c.errs.Collect(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	type row struct {
+		UserID string
+		Count  int64
+	}
+	var rows []row
+	if err := c.db.WithContext(ctx).
+		Table("pr_reviewers AS r").
+		Select("r.reviewer_id AS user_id, COUNT(*) AS count").
+		Joins("JOIN pull_requests pr ON pr.pull_request_id = r.pr_id").
+		Where("pr.status = 'OPEN'").
+		Group("r.reviewer_id").
+		Scan(&rows).Error; err != nil {
+		c.errs.Inc()
+		return
+	}
+	for _, x := range rows {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(x.Count), x.UserID)
+	}
+}
@@ -5,7 +5,9 @@ import "time"
 
 // TeamDB маппится на таблицу teams
 type TeamDB struct {
-	TeamName string `gorm:"primaryKey;column:team_name"`
+	TeamName           string `gorm:"primaryKey;column:team_name"`
+	MinSeniorReviewers int16  `gorm:"column:min_senior_reviewers"` // сколько senior нужно среди ревьюверов PR
+	MaxOpenReviews     *int   `gorm:"column:max_open_reviews"`     // лимит открытых ревью по умолчанию; nil — без ограничения
 }
 
 // TableName возвращает имя таблицы для TeamDB
@@ -19,8 +21,18 @@ type UserDB struct {
 	Username string `gorm:"column:username"`
 	IsActive bool   `gorm:"column:is_active"`
 	TeamName string `gorm:"column:team_name"`
+	Level    string `gorm:"column:level;default:mid"` // см. Level*
+	// MaxOpenReviews — лимит открытых ревью; nil — лимит команды
+	MaxOpenReviews *int `gorm:"column:max_open_reviews"`
 }
 
+// Уровни пользователей (users.level)
+const (
+	LevelJunior = "junior"
+	LevelMid    = "mid"
+	LevelSenior = "senior"
+)
+
 // TableName возвращает имя таблицы для UserDB
 func (UserDB) TableName() string { return "users" }
 
@@ -32,6 +44,7 @@ type PullRequestDB struct {
 	Status    string     `gorm:"column:status"`
 	CreatedAt time.Time  `gorm:"column:created_at"`
 	MergedAt  *time.Time `gorm:"column:merged_at"`
+	Version   int64      `gorm:"column:version;default:1"` // растёт при каждом изменении PR
 }
 
 // TableName возвращает имя таблицы для PullRequestDB
@@ -42,7 +55,97 @@ type PRReviewerDB struct {
 	PRID       string `gorm:"primaryKey;column:pr_id"`
 	ReviewerID string `gorm:"column:reviewer_id"`
 	Position   int16  `gorm:"primaryKey;column:position"`
+	Pinned     bool   `gorm:"column:pinned"` // выбран вручную, автоматически не переназначается
 }
 
 // TableName возвращает имя таблицы для PRReviewerDB
 func (PRReviewerDB) TableName() string { return "pr_reviewers" }
+
+// Виды событий истории PR
+const (
+	EventCreated     = "CREATED"
+	EventAssigned    = "ASSIGNED"     // ревьювер назначен при создании PR
+	EventReassigned  = "REASSIGNED"   // old_user_id заменён на new_user_id
+	EventUnassigned  = "UNASSIGNED"   // old_user_id снят без замены
+	EventNoCandidate = "NO_CANDIDATE" // слот не удалось заполнить
+	EventMerged      = "MERGED"
+)
+
+// Причины отказа ревьювера (pr_events.reason)
+const (
+	DeclineBusy               = "BUSY"
+	DeclineLacksContext       = "LACKS_CONTEXT"
+	DeclineConflictOfInterest = "CONFLICT_OF_INTEREST"
+)
+
+// PREventDB маппится на таблицу pr_events (история PR)
+type PREventDB struct {
+	ID        int64     `gorm:"primaryKey;column:id"`
+	PRID      string    `gorm:"column:pr_id"`
+	Kind      string    `gorm:"column:kind"`
+	OldUserID *string   `gorm:"column:old_user_id"`
+	NewUserID *string   `gorm:"column:new_user_id"`
+	Reason    *string   `gorm:"column:reason"` // причина отказа ревьювера, см. Decline*
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// TableName возвращает имя таблицы для PREventDB
+func (PREventDB) TableName() string { return "pr_events" }
+
+// UserActivityDB маппится на таблицу user_activity (смены is_active, для отчёта о справедливости)
+type UserActivityDB struct {
+	ID        int64     `gorm:"primaryKey;column:id"`
+	UserID    string    `gorm:"column:user_id"`
+	IsActive  bool      `gorm:"column:is_active"`
+	ChangedAt time.Time `gorm:"column:changed_at"`
+}
+
+// TableName возвращает имя таблицы для UserActivityDB
+func (UserActivityDB) TableName() string { return "user_activity" }
+
+// Виды правил назначения (reviewer_rules.kind)
+const (
+	RuleExclude = "EXCLUDE" // reviewer_id не назначается на PR author_id
+	RuleRequire = "REQUIRE" // на PR author_id назначается кто-то из его REQUIRE-правил
+)
+
+// ReviewerRuleDB маппится на таблицу reviewer_rules (правила для пар автор/ревьювер)
+type ReviewerRuleDB struct {
+	ID         int64     `gorm:"primaryKey;column:id"`
+	Kind       string    `gorm:"column:kind"`
+	TeamName   *string   `gorm:"column:team_name"` // nil — глобальное правило, иначе действует, пока автор в команде
+	AuthorID   string    `gorm:"column:author_id"`
+	ReviewerID string    `gorm:"column:reviewer_id"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+}
+
+// TableName возвращает имя таблицы для ReviewerRuleDB
+func (ReviewerRuleDB) TableName() string { return "reviewer_rules" }
+
+// IdempotencyKeyDB маппится на таблицу idempotency_keys (сохранённые ответы для повторов)
+type IdempotencyKeyDB struct {
+	Scope       string    `gorm:"primaryKey;column:scope"`
+	Key         string    `gorm:"primaryKey;column:key"`
+	RequestHash string    `gorm:"column:request_hash"`
+	StatusCode  *int      `gorm:"column:status_code"`
+	ContentType string    `gorm:"column:content_type"`
+	ETag        string    `gorm:"column:etag"`
+	Body        []byte    `gorm:"column:body"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+	ExpiresAt   time.Time `gorm:"column:expires_at"`
+}
+
+// TableName возвращает имя таблицы для IdempotencyKeyDB
+func (IdempotencyKeyDB) TableName() string { return "idempotency_keys" }
+
+// SchemaVersion — версия схемы БД, которую ожидает код (номер последней миграции)
+const SchemaVersion = 12
+
+// SchemaMigrationDB маппится на таблицу schema_migrations (применённые миграции)
+type SchemaMigrationDB struct {
+	Version   int       `gorm:"primaryKey;column:version"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+// TableName возвращает имя таблицы для SchemaMigrationDB
+func (SchemaMigrationDB) TableName() string { return "schema_migrations" }
@@ -0,0 +1,210 @@
+// Package selector выбирает ревьюверов из подходящих кандидатов
+package selector
+
+import (
+	"fmt"
+	"maps"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Candidate — кандидат в ревьюверы
+type Candidate struct {
+	UserID      string
+	OpenReviews int64  // сколько открытых PR уже на ревью у кандидата
+	Level       string // junior | mid | senior
+	Capacity    int64  // сколько открытых ревью можно держать; 0 — без ограничения
+}
+
+// full сообщает, что у кандидата с open открытыми ревью нет места под новое
+func (c Candidate) full(open int64) bool { return c.Capacity > 0 && open >= c.Capacity }
+
+// available — кандидаты, у которых есть место под новое ревью
+func available(cands []Candidate) []Candidate {
+	out := make([]Candidate, 0, len(cands))
+	for _, c := range cands {
+		if !c.full(c.OpenReviews) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Strategy выбирает до n ревьюверов из кандидатов.
+// Кандидаты уже отфильтрованы (активные, не автор, не текущие ревьюверы);
+// тех, кто достиг своего Capacity, стратегия пропускает
+type Strategy interface {
+	Pick(cands []Candidate, n int) []Candidate
+}
+
+// Composition — требование к составу ревьюверов: не меньше Min кандидатов уровня Level
+type Composition struct {
+	Level string
+	Min   int
+}
+
+// PickComposed выбирает до n кандидатов стратегией s так, чтобы вместе с уже назначенными
+// (have из них — уровня c.Level) выполнялось требование c: недостающие кандидаты уровня c.Level
+// выбираются первыми, остальные слоты — из всех. Если нужного уровня не хватает, берутся сколько есть
+func PickComposed(s Strategy, cands []Candidate, n int, c Composition, have int) []Candidate {
+	need := min(c.Min-have, n)
+	if need <= 0 {
+		return s.Pick(cands, n)
+	}
+	var level, rest []Candidate
+	for _, x := range cands {
+		if x.Level == c.Level {
+			level = append(level, x)
+		}
+	}
+	out := s.Pick(level, need)
+	picked := make(map[string]bool, len(out))
+	for _, x := range out {
+		picked[x.UserID] = true
+	}
+	for _, x := range cands {
+		if !picked[x.UserID] {
+			rest = append(rest, x)
+		}
+	}
+	return append(out, s.Pick(rest, n-len(out))...)
+}
+
+// CountLevel — сколько кандидатов уровня level
+func CountLevel(cands []Candidate, level string) int {
+	n := 0
+	for _, c := range cands {
+		if c.Level == level {
+			n++
+		}
+	}
+	return n
+}
+
+// Названия стратегий для конфигурации
+const (
+	StrategyRandom      = "random"
+	StrategyLeastLoaded = "least_loaded"
+)
+
+// New возвращает стратегию по названию
+func New(name string) (Strategy, error) {
+	switch name {
+	case StrategyRandom, "":
+		return NewRandom(), nil
+	case StrategyLeastLoaded:
+		return NewLeastLoaded(), nil
+	default:
+		return nil, fmt.Errorf("selector: unknown strategy %q", name)
+	}
+}
+
+// Seeded возвращает копию стратегии s со своим источником случайности, засеянным seed:
+// при тех же кандидатах (в том же порядке) и той же последовательности вызовов выбор повторяется.
+// seed 0 — зерно от текущего времени; стратегии без случайности возвращаются как есть
+func Seeded(s Strategy, seed int64) Strategy {
+	switch s := s.(type) {
+	case *Random:
+		return &Random{rnd: newLockedRand(seed)}
+	case *LeastLoaded:
+		return &LeastLoaded{rnd: newLockedRand(seed)}
+	case *Batch:
+		return &Batch{inner: Seeded(s.inner, seed), taken: maps.Clone(s.taken)}
+	default:
+		return s
+	}
+}
+
+// lockedRand — источник случайности, безопасный для конкурентных запросов
+type lockedRand struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// newLockedRand создаёт источник с зерном seed; 0 — зерно от текущего времени
+func newLockedRand(seed int64) *lockedRand {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &lockedRand{rnd: rand.New(rand.NewSource(seed))}
+}
+
+func (r *lockedRand) shuffle(c []Candidate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rnd.Shuffle(len(c), func(i, j int) { c[i], c[j] = c[j], c[i] })
+}
+
+// Random — равновероятный выбор
+type Random struct{ rnd *lockedRand }
+
+// NewRandom создаёт стратегию Random
+func NewRandom() *Random { return &Random{rnd: newLockedRand(0)} }
+
+// Pick реализует Strategy
+func (s *Random) Pick(cands []Candidate, n int) []Candidate {
+	c := available(cands)
+	s.rnd.shuffle(c)
+	return c[:min(n, len(c))]
+}
+
+// LeastLoaded — сначала кандидаты с наименьшим числом открытых ревью,
+// при равенстве — случайно
+type LeastLoaded struct{ rnd *lockedRand }
+
+// NewLeastLoaded создаёт стратегию LeastLoaded
+func NewLeastLoaded() *LeastLoaded { return &LeastLoaded{rnd: newLockedRand(0)} }
+
+// Pick реализует Strategy
+func (s *LeastLoaded) Pick(cands []Candidate, n int) []Candidate {
+	c := available(cands)
+	s.rnd.shuffle(c)
+	sort.SliceStable(c, func(i, j int) bool { return c[i].OpenReviews < c[j].OpenReviews })
+	return c[:min(n, len(c))]
+}
+
+// Batch — обёртка для выбора ревьюверов сразу для многих PR (createBatch).
+// Помнит, сколько раз кандидат уже выбран в этой пачке: сначала берёт наименее
+// выбранных, среди равных решает вложенная стратегия. Выбор учитывается и в
+// OpenReviews, чтобы LeastLoaded видел нагрузку, набранную внутри пачки.
+// Не безопасна для конкурентного использования: одна пачка — один Batch
+type Batch struct {
+	inner Strategy
+	taken map[string]int64
+}
+
+// NewBatch создаёт Batch поверх стратегии s
+func NewBatch(s Strategy) *Batch { return &Batch{inner: s, taken: map[string]int64{}} }
+
+// Pick реализует Strategy
+func (b *Batch) Pick(cands []Candidate, n int) []Candidate {
+	byTaken := map[int64][]Candidate{}
+	var levels []int64
+	for _, c := range cands {
+		k := b.taken[c.UserID]
+		if c.full(c.OpenReviews + k) {
+			continue // место занято назначениями этой пачки
+		}
+		if _, ok := byTaken[k]; !ok {
+			levels = append(levels, k)
+		}
+		c.OpenReviews += k
+		byTaken[k] = append(byTaken[k], c)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+
+	var out []Candidate
+	for _, k := range levels {
+		if len(out) >= n {
+			break
+		}
+		out = append(out, b.inner.Pick(byTaken[k], n-len(out))...)
+	}
+	for i := range out {
+		b.taken[out[i].UserID]++
+		out[i].OpenReviews -= b.taken[out[i].UserID] - 1
+	}
+	return out
+}
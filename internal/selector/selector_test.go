@@ -0,0 +1,104 @@
+package selector_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/alinaaved/pr-reviewer/internal/selector"
+)
+
+func TestBatch_SpreadsPicksEvenly(t *testing.T) {
+	cands := []selector.Candidate{{UserID: "u1"}, {UserID: "u2"}, {UserID: "u3"}, {UserID: "u4"}}
+	b := selector.NewBatch(selector.NewRandom())
+	got := map[string]int{}
+	// 6 PR по 2 ревьювера на 4 кандидатов — ровно по 3 назначения каждому
+	for range 6 {
+		picked := b.Pick(cands, 2)
+		if len(picked) != 2 || picked[0].UserID == picked[1].UserID {
+			t.Fatalf("bad pick: %+v", picked)
+		}
+		for _, c := range picked {
+			got[c.UserID]++
+		}
+	}
+	for _, c := range cands {
+		if got[c.UserID] != 3 {
+			t.Fatalf("uneven distribution: %v", got)
+		}
+	}
+}
+
+func TestBatch_KeepsInnerOrderWithinLevel(t *testing.T) {
+	cands := []selector.Candidate{{UserID: "u1", OpenReviews: 5}, {UserID: "u2", OpenReviews: 0}, {UserID: "u3", OpenReviews: 1}}
+	b := selector.NewBatch(selector.NewLeastLoaded())
+	if p := b.Pick(cands, 1); p[0].UserID != "u2" || p[0].OpenReviews != 0 {
+		t.Fatalf("first pick: %+v", p)
+	}
+	// u2 уже выбран в пачке — дальше u3, затем u1
+	if p := b.Pick(cands, 2); p[0].UserID != "u3" || p[1].UserID != "u1" {
+		t.Fatalf("second pick: %+v", p)
+	}
+}
+
+func TestPickComposed_SeniorFirst(t *testing.T) {
+	cands := []selector.Candidate{
+		{UserID: "j1", Level: "junior"}, {UserID: "j2", Level: "junior"},
+		{UserID: "j3", Level: "junior"}, {UserID: "s1", Level: "senior"},
+	}
+	c := selector.Composition{Level: "senior", Min: 1}
+	for range 20 {
+		p := selector.PickComposed(selector.NewRandom(), cands, 2, c, 0)
+		if len(p) != 2 || p[0].UserID != "s1" || p[1].UserID == "s1" {
+			t.Fatalf("pick: %+v", p)
+		}
+	}
+	// senior уже назначен — ограничений нет
+	if p := selector.PickComposed(selector.NewLeastLoaded(), cands[:3], 1, c, 1); len(p) != 1 {
+		t.Fatalf("pick with senior assigned: %+v", p)
+	}
+	// senior нет среди кандидатов — берутся остальные
+	if p := selector.PickComposed(selector.NewRandom(), cands[:3], 2, c, 0); len(p) != 2 || selector.CountLevel(p, "senior") != 0 {
+		t.Fatalf("pick without seniors: %+v", p)
+	}
+}
+
+func TestPick_SkipsFullCandidates(t *testing.T) {
+	cands := []selector.Candidate{
+		{UserID: "u1", OpenReviews: 2, Capacity: 2},
+		{UserID: "u2", OpenReviews: 5},
+		{UserID: "u3", OpenReviews: 0, Capacity: 1},
+	}
+	for _, s := range []selector.Strategy{selector.NewRandom(), selector.NewLeastLoaded()} {
+		p := s.Pick(cands, 3)
+		if len(p) != 2 || p[0].UserID == "u1" || p[1].UserID == "u1" {
+			t.Fatalf("%T: %+v", s, p)
+		}
+	}
+	// u3 получает одно ревью в пачке и дальше не выбирается
+	b := selector.NewBatch(selector.NewLeastLoaded())
+	if p := b.Pick(cands, 1); len(p) != 1 || p[0].UserID != "u3" {
+		t.Fatalf("first batch pick: %+v", p)
+	}
+	if p := b.Pick(cands, 2); len(p) != 1 || p[0].UserID != "u2" {
+		t.Fatalf("second batch pick: %+v", p)
+	}
+}
+
+func TestSeeded_Reproducible(t *testing.T) {
+	cands := []selector.Candidate{{UserID: "u1"}, {UserID: "u2"}, {UserID: "u3"}, {UserID: "u4"}, {UserID: "u5"}}
+	ids := func(s selector.Strategy) []string {
+		var out []string
+		for i := 0; i < 5; i++ {
+			for _, c := range s.Pick(cands, 2) {
+				out = append(out, c.UserID)
+			}
+		}
+		return out
+	}
+	for _, s := range []selector.Strategy{selector.NewRandom(), selector.NewLeastLoaded(), selector.NewBatch(selector.NewRandom())} {
+		a, b := ids(selector.Seeded(s, 42)), ids(selector.Seeded(s, 42))
+		if !slices.Equal(a, b) {
+			t.Fatalf("%T: same seed, different picks: %v vs %v", s, a, b)
+		}
+	}
+}
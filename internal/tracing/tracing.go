@@ -0,0 +1,96 @@
+// Package tracing — трейсинг OpenTelemetry: экспорт по OTLP, спаны HTTP и GORM
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/alinaaved/pr-reviewer/internal/httpx"
+)
+
+// instrumentation — имя трейсера сервиса
+const instrumentation = "github.com/alinaaved/pr-reviewer"
+
+// DefaultServiceName — service.name, если OTEL_SERVICE_NAME не задан
+const DefaultServiceName = "pr-reviewer"
+
+func tracer() trace.Tracer { return otel.Tracer(instrumentation) }
+
+// Enabled сообщает, задан ли OTLP endpoint стандартными переменными OTEL_*
+func Enabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// SetupOTLP включает экспорт трейсов по OTLP/HTTP. Endpoint, заголовки,
+// сэмплер и service.name настраиваются стандартными переменными OTEL_*
+func SetupOTLP(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	exp, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Install(sdktrace.WithBatcher(exp))
+}
+
+// Install создаёт TracerProvider с заданными опциями (экспортёр, сэмплер),
+// делает его глобальным и включает W3C traceparent/baggage
+func Install(opts ...sdktrace.TracerProviderOption) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(DefaultServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+	// OTEL_SERVICE_NAME/OTEL_RESOURCE_ATTRIBUTES важнее значения по умолчанию
+	if env, err := resource.New(context.Background(), resource.WithFromEnv()); err == nil {
+		if merged, err := resource.Merge(res, env); err == nil {
+			res = merged
+		}
+	}
+	tp := sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{sdktrace.WithResource(res)}, opts...)...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+	return tp, nil
+}
+
+// Middleware открывает серверный спан на запрос, продолжая trace из traceparent.
+// Имя спана — метод и шаблон маршрута chi
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		sw := httpx.Wrap(w)
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rc.RoutePattern())
+			span.SetAttributes(semconv.HTTPRoute(rc.RoutePattern()))
+		}
+		span.SetAttributes(attribute.Int(string(semconv.HTTPResponseStatusCodeKey), sw.Status()))
+		if sw.Status() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.Status()))
+		}
+	})
+}
@@ -24,8 +24,15 @@ export function setup() {
   check(res, { 'seed ok': r => r.status === 201 || r.status === 400 });
 }
 
+// BATCH=N — создавать по N PR одним /pullRequest/createBatch (как стек из монорепо)
+const BATCH = parseInt(__ENV.BATCH || '0', 10);
+
 // основной сценарий: create -> merge (идемпотентность не проверяем тут, можно добавить при желании)
 export default function () {
+  if (BATCH > 0) {
+    batch();
+    return;
+  }
   const id = `pr-${__VU}-${Date.now()}`;
   let r = http.post(`${BASE}/pullRequest/create`,
     JSON.stringify({ pull_request_id: id, pull_request_name: 'load', author_id: 'u1' }),
@@ -39,3 +46,13 @@ export default function () {
 
   sleep(0.2);
 }
+
+function batch() {
+  const prs = [];
+  for (let i = 0; i < BATCH; i++) {
+    prs.push({ pull_request_id: `pr-${__VU}-${Date.now()}-${i}`, pull_request_name: 'load', author_id: 'u1' });
+  }
+  const r = http.post(`${BASE}/pullRequest/createBatch`, JSON.stringify({ pull_requests: prs }), { headers: H });
+  check(r, { 'batch=200': res => res.status === 200 && res.json('failed') === 0 });
+  sleep(0.2);
+}